@@ -0,0 +1,434 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"os"
+	"runtime"
+	runtimemetrics "runtime/metrics"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/pipawoz/go_genetic_algorithm/internal/dashboard"
+	"github.com/pipawoz/go_genetic_algorithm/internal/engine"
+	"github.com/pipawoz/go_genetic_algorithm/internal/metrics"
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/internal/sweep"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// newFlagSet creates the flag set of a subcommand with the configuration flags.
+func newFlagSet(name string) (*flag.FlagSet, *utils.Overrides) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+
+	configDir := utils.ConfigDir
+	if env, ok := os.LookupEnv(utils.EnvPrefix + "CONFIG"); ok {
+		configDir = env
+	}
+
+	fs.StringVar(&utils.ConfigDir, "config", configDir, "directory holding settings.json and genetic_settings.json")
+	overrides := utils.BindSettingsFlags(fs, &utils.Settings, &utils.DNASettings)
+
+	return fs, overrides
+}
+
+// loadSettings parses the flags, loads the settings and validates them.
+// The JSON files are read first, then the environment and the flags override them.
+func loadSettings(fs *flag.FlagSet, overrides *utils.Overrides, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	_, gameErr := utils.LoadGameSettings()
+	_, geneticErr := utils.LoadGeneticSettings()
+
+	if err := utils.ApplyEnv(&utils.Settings, &utils.DNASettings); err != nil {
+		return err
+	}
+
+	if err := overrides.Apply(); err != nil {
+		return err
+	}
+
+	if utils.Settings.LevelsFile != "" {
+		// Problems with the levels file are reported by the validation below.
+		_ = utils.LoadLevels(utils.Settings.LevelsFile)
+	}
+
+	if err := engine.ValidateConfig(); err != nil {
+		return err
+	}
+
+	slog.SetDefault(utils.NewLogger(os.Stdout, utils.Settings))
+
+	// rand.Seed is deprecated, but the whole simulation draws from the global source.
+	if utils.DNASettings.Seed != 0 {
+		rand.Seed(utils.DNASettings.Seed)
+	}
+
+	return errors.Join(gameErr, geneticErr)
+}
+
+// newGame creates a game from the loaded settings, seeded with the seed genomes if configured.
+func newGame() (*engine.Game, error) {
+	var seeds []population.DNA
+	if utils.Settings.SeedGenomeFile != "" {
+		var err error
+		seeds, err = population.LoadGenomes(utils.Settings.SeedGenomeFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	populationSize := utils.DNASettings.PopulationSize
+	maxGenerations := utils.DNASettings.MaxGenerations
+	showTrails := utils.Settings.PrintTrace
+
+	return engine.NewGame(populationSize, maxGenerations, showTrails, seeds), nil
+}
+
+// startServers Serves the dashboard and the metrics of the game on the addresses set in
+// dashboardAddr and metricsAddr, if any. The returned function stops the servers.
+func startServers(game *engine.Game) (func(), error) {
+	var servers []*http.Server
+	stop := func() {
+		for _, server := range servers {
+			server.Close()
+		}
+	}
+
+	if utils.Settings.DashboardAddr != "" {
+		board := dashboard.New()
+		server, err := board.Start(utils.Settings.DashboardAddr)
+		if err != nil {
+			return nil, err
+		}
+
+		servers = append(servers, server)
+		game.AddObserver(board)
+		game.SetController(board)
+	}
+
+	if utils.Settings.MetricsAddr != "" {
+		collector := metrics.NewCollector()
+		server, err := collector.Start(utils.Settings.MetricsAddr)
+		if err != nil {
+			stop()
+			return nil, err
+		}
+
+		servers = append(servers, server)
+		game.AddObserver(collector)
+	}
+
+	return stop, nil
+}
+
+// runCommand Evolves the population in a window, or headless when simulateOnly is set.
+func runCommand(args []string) error {
+	fs, overrides := newFlagSet("run")
+	if err := loadSettings(fs, overrides, args); err != nil {
+		return err
+	}
+
+	game, err := newGame()
+	if err != nil {
+		return err
+	}
+
+	stopServers, err := startServers(game)
+	if err != nil {
+		return err
+	}
+	defer stopServers()
+
+	if utils.Settings.SimulateOnly {
+		return game.RunHeadless()
+	}
+
+	ebiten.SetWindowSize(utils.GameWidth, utils.GameHeight)
+	ebiten.SetWindowTitle("Go - Genetic Algorithm Maze")
+
+	ebiten.SetTPS(60)
+
+	err = ebiten.RunGame(game)
+	if err != nil && !errors.Is(err, engine.ErrMaxGenerations) && !errors.Is(err, engine.ErrStopped) {
+		return err
+	}
+
+	return nil
+}
+
+// headlessCommand Evolves the population without opening a window.
+// With --summary-file, the outcome of the run is written there as JSON.
+func headlessCommand(args []string) error {
+	fs, overrides := newFlagSet("headless")
+	summaryFile := fs.String("summary-file", "", "write the outcome of the run to this JSON file")
+	if err := loadSettings(fs, overrides, args); err != nil {
+		return err
+	}
+
+	game, err := newGame()
+	if err != nil {
+		return err
+	}
+
+	stopServers, err := startServers(game)
+	if err != nil {
+		return err
+	}
+	defer stopServers()
+
+	if err := game.RunHeadless(); err != nil {
+		return err
+	}
+
+	if *summaryFile == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(game.Summary())
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(*summaryFile, data, 0o644)
+}
+
+// replayCommand Plays back the genomes saved in the given files on the configured level.
+func replayCommand(args []string) error {
+	fs, overrides := newFlagSet("replay")
+	if err := loadSettings(fs, overrides, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return errors.New("replay needs at least one genome file")
+	}
+
+	var genomes []population.DNA
+	for _, path := range fs.Args() {
+		loaded, err := population.LoadGenomes(path)
+		if err != nil {
+			return err
+		}
+		genomes = append(genomes, loaded...)
+	}
+
+	ebiten.SetWindowSize(utils.GameWidth, utils.GameHeight)
+	ebiten.SetWindowTitle("Go - Genetic Algorithm Maze - Replay")
+
+	ebiten.SetTPS(60)
+
+	replay := engine.NewReplay(genomes, utils.Settings.CurrentLevel)
+	if utils.Settings.RecordFile != "" {
+		replay.Record(utils.Settings.RecordFile, utils.Settings.RecordFrameStep)
+	}
+
+	return ebiten.RunGame(replay)
+}
+
+// benchCommand Measures how fast generations are simulated without a window,
+// or how fast they are drawn in one with --draw.
+func benchCommand(args []string) error {
+	fs, overrides := newFlagSet("bench")
+	generations := fs.Int("generations", 5, "number of generations to simulate")
+	drawing := fs.Bool("draw", false, "draw every frame in a window, as fast as possible")
+	perShape := fs.Bool("per-shape", false, "with --draw, draw every rectangle from an image of its own, as the window used to")
+	if err := loadSettings(fs, overrides, args); err != nil {
+		return err
+	}
+
+	utils.DNASettings.MaxGenerations = *generations
+	// The benchmark steps Game.Update without the game loop, where frames cannot be recorded.
+	utils.Settings.RecordFile = ""
+
+	game, err := newGame()
+	if err != nil {
+		return err
+	}
+
+	if *drawing {
+		game.SetPerShapeDrawing(*perShape)
+		return benchDraw(game, *generations)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	frames := 0
+	start := time.Now()
+	generationStart := start
+
+	for {
+		generation := game.Generation()
+		err := game.Update()
+		if errors.Is(err, engine.ErrMaxGenerations) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		frames++
+		if game.Generation() != generation {
+			slog.Info("generation simulated", "generation", generation, "duration", time.Since(generationStart))
+			generationStart = time.Now()
+		}
+	}
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	fmt.Println("")
+	fmt.Println("*** Benchmark ***")
+	fmt.Println("Generations: ", *generations)
+	fmt.Println("Population: ", utils.DNASettings.PopulationSize)
+	fmt.Println("Total time: ", elapsed)
+	fmt.Printf("Frames/s: %.0f\n", float64(frames)/elapsed.Seconds())
+	fmt.Printf("Generations/s: %.2f\n", float64(*generations)/elapsed.Seconds())
+	fmt.Printf("Allocations/frame: %.1f\n", float64(after.Mallocs-before.Mallocs)/float64(max(frames, 1)))
+
+	return nil
+}
+
+// drawBench Runs a game in the window without waiting for the display, and measures its Draw.
+type drawBench struct {
+	*engine.Game
+	frames      int
+	drawTime    time.Duration
+	allocations uint64
+	sample      []runtimemetrics.Sample
+}
+
+// Draw Draws the game and adds the time and the allocations it took.
+func (b *drawBench) Draw(screen *ebiten.Image) {
+	runtimemetrics.Read(b.sample)
+	before := b.sample[0].Value.Uint64()
+	start := time.Now()
+
+	b.Game.Draw(screen)
+
+	b.drawTime += time.Since(start)
+	runtimemetrics.Read(b.sample)
+	b.allocations += b.sample[0].Value.Uint64() - before
+	b.frames++
+}
+
+// benchDraw Simulates the generations in a window, drawing every frame as fast as possible.
+// The draw time is spent on the CPU building the frame; the GPU work is counted in the frame rate.
+func benchDraw(game *engine.Game, generations int) error {
+	bench := &drawBench{
+		Game:   game,
+		sample: []runtimemetrics.Sample{{Name: "/gc/heap/allocs:objects"}},
+	}
+
+	ebiten.SetWindowSize(utils.GameWidth, utils.GameHeight)
+	ebiten.SetWindowTitle("Go - Genetic Algorithm Maze - Benchmark")
+	ebiten.SetVsyncEnabled(false)
+	ebiten.SetTPS(ebiten.SyncWithFPS)
+
+	start := time.Now()
+	err := ebiten.RunGame(bench)
+	if err != nil && !errors.Is(err, engine.ErrMaxGenerations) && !errors.Is(err, engine.ErrStopped) {
+		return err
+	}
+	elapsed := time.Since(start)
+	frames := max(bench.frames, 1)
+
+	fmt.Println("")
+	fmt.Println("*** Draw benchmark ***")
+	fmt.Println("Generations: ", generations)
+	fmt.Println("Population: ", utils.DNASettings.PopulationSize)
+	fmt.Println("Total time: ", elapsed)
+	fmt.Printf("Frames/s: %.0f\n", float64(bench.frames)/elapsed.Seconds())
+	fmt.Println("Draw time/frame: ", bench.drawTime/time.Duration(frames))
+	fmt.Printf("Draw allocations/frame: %.1f\n", float64(bench.allocations)/float64(frames))
+
+	return nil
+}
+
+// sweepCommand Runs every configuration of a sweep spec with every seed, in parallel headless
+// processes, and writes a results table. The flags given to the command apply to every run.
+func sweepCommand(args []string) error {
+	fs, overrides := newFlagSet("sweep")
+	parallel := fs.Int("parallel", runtime.NumCPU(), "number of runs at once")
+	resultsFile := fs.String("results", "sweep_results.csv", "results table, as JSON when it ends in .json and CSV otherwise")
+	if err := loadSettings(fs, overrides, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("sweep needs a spec file: sweep [flags] SPEC")
+	}
+
+	spec, err := sweep.LoadSpec(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	configs := spec.Configurations(rand.New(rand.NewSource(spec.Seeds[0])))
+	runs := sweep.Runs(configs, spec.Seeds)
+	slog.Info("sweep started", "configurations", len(configs), "seeds", len(spec.Seeds), "runs", len(runs), "parallel", *parallel)
+
+	runner := &sweep.Runner{
+		Executable: executable,
+		Args:       append([]string{"--config=" + utils.ConfigDir}, overrides.Args()...),
+		Parallel:   *parallel,
+	}
+
+	results, err := runner.Run(runs, func(done int, result sweep.Result) {
+		attrs := []any{"done", done, "runs", len(runs), "config", result.Config.String(), "seed", result.Seed,
+			"duration", result.Duration.Round(time.Millisecond)}
+		if result.Err != nil {
+			slog.Warn("sweep run failed", append(attrs, "err", result.Err)...)
+		} else {
+			slog.Info("sweep run finished", append(attrs, "bestFitness", result.BestFitness)...)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := sweep.Save(*resultsFile, spec.Keys(), results); err != nil {
+		return err
+	}
+
+	fmt.Println("")
+	fmt.Println("*** Sweep ***")
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Configuration\tSolved\tAvg first win\tAvg best fitness\tAvg win rate")
+	for _, aggregate := range sweep.Summarize(results) {
+		firstWin := "-"
+		if aggregate.Solved > 0 {
+			firstWin = fmt.Sprintf("%.1f", aggregate.AvgFirstWin)
+		}
+		fmt.Fprintf(table, "%s\t%d/%d\t%s\t%.4f\t%.1f%%\n", aggregate.Config, aggregate.Solved, aggregate.Runs, firstWin, aggregate.AvgBestFitness, aggregate.AvgWinRate*100)
+	}
+	table.Flush()
+	fmt.Println("Results saved to ", *resultsFile)
+
+	return nil
+}
+
+// validateCommand Checks the configuration files, the settings and the levels.
+func validateCommand(args []string) error {
+	fs, overrides := newFlagSet("validate")
+	if err := loadSettings(fs, overrides, args); err != nil {
+		return err
+	}
+
+	fmt.Println("Configuration is valid")
+	return nil
+}
@@ -2,32 +2,60 @@
 package main
 
 import (
-	"log"
+	"fmt"
 	"math/rand"
-
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/pipawoz/go_genetic_algorithm/internal/engine"
-	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+	"os"
+	"strings"
 )
 
-func main() {
-	utils.LoadGameSettings()
-	utils.LoadGeneticSettings()
+// usage is printed when the subcommand is unknown.
+const usage = `Usage: go_genetic_algorithm [command] [flags]
 
-	populationSize := utils.DNASettings.PopulationSize
-	maxGenerations := utils.DNASettings.MaxGenerations
-	showTrails := utils.Settings.PrintTrace
+Commands:
+  run       evolve the population in a window (default)
+  headless  evolve the population without a window
+  replay    play back saved genomes: replay [flags] FILE...
+  bench     measure the simulation speed
+  sweep     compare settings over several seeds: sweep [flags] SPEC
+  validate  check the configuration files
 
-	rand.New(rand.NewSource(rand.Int63()))
+Every command accepts --config DIR and a flag for each setting, e.g. --population-size 200.
+Settings can also be overridden with GGA_* environment variables, e.g. GGA_MUTATION_RATE=0.1.
+Run "go_genetic_algorithm COMMAND -h" for the flags of a command.
+`
 
-	ebiten.SetWindowSize(utils.GameWidth, utils.GameHeight)
-	ebiten.SetWindowTitle("Go - Genetic Algorithm Maze")
+func main() {
+	command, args := "run", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
 
-	ebiten.SetTPS(60)
+	rand.New(rand.NewSource(rand.Int63()))
 
-	game := engine.NewGame(populationSize, maxGenerations, showTrails)
+	var err error
+	switch command {
+	case "run":
+		err = runCommand(args)
+	case "headless":
+		err = headlessCommand(args)
+	case "replay":
+		err = replayCommand(args)
+	case "bench":
+		err = benchCommand(args)
+	case "sweep":
+		err = sweepCommand(args)
+	case "validate":
+		err = validateCommand(args)
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
 
-	if err := ebiten.RunGame(game); err != nil {
-		log.Fatal(err)
+	// Errors go to stderr as they are: the logger may be quiet or write JSON to stdout.
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
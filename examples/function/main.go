@@ -0,0 +1,93 @@
+// Command function minimizes the Rastrigin function, a real-valued benchmark with many local minima
+// and its global minimum of 0 at the origin. The genomes are vectors of float64 bred with
+// blend crossover and Gaussian mutation.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/pipawoz/go_genetic_algorithm/pkg/ga"
+)
+
+// bound is the limit of every coordinate, the usual domain of the Rastrigin function.
+const bound = 5.12
+
+func main() {
+	dimensions := flag.Int("dimensions", 5, "number of variables")
+	generations := flag.Int("generations", 500, "number of generations")
+	seed := flag.Int64("seed", 1, "random seed")
+	flag.Parse()
+
+	engine := ga.Engine[[]float64]{
+		Config: ga.Config{
+			PopulationSize: 150,
+			Generations:    *generations,
+			MutationRate:   0.1,
+			CrossoverRate:  0.9,
+			Elitism:        2,
+			Selection:      ga.Tournament(3),
+			Rand:           rand.New(rand.NewSource(*seed)),
+		},
+		// The fitness is maximized, so the function is negated.
+		Evaluate: func(x []float64) float64 {
+			return -rastrigin(x)
+		},
+		Random: func(rng *rand.Rand) []float64 {
+			x := make([]float64, *dimensions)
+			for i := range x {
+				x[i] = (rng.Float64()*2 - 1) * bound
+			}
+			return x
+		},
+		// Every coordinate of the children is a random mix of the coordinates of the parents.
+		Crossover: func(a, b []float64, rng *rand.Rand) ([]float64, []float64) {
+			childA, childB := make([]float64, len(a)), make([]float64, len(a))
+			for i := range a {
+				weight := rng.Float64()
+				childA[i] = weight*a[i] + (1-weight)*b[i]
+				childB[i] = (1-weight)*a[i] + weight*b[i]
+			}
+			return childA, childB
+		},
+		// Every coordinate moves by a Gaussian step with the given rate.
+		Mutate: func(x []float64, rate float64, rng *rand.Rand) []float64 {
+			x = slices.Clone(x)
+			for i := range x {
+				if rng.Float64() < rate {
+					x[i] = math.Max(-bound, math.Min(bound, x[i]+rng.NormFloat64()*0.3))
+				}
+			}
+			return x
+		},
+		Callbacks: ga.Callbacks[[]float64]{
+			OnGeneration: func(stats ga.Stats, population ga.Population[[]float64]) bool {
+				if stats.Generation%50 == 0 {
+					fmt.Printf("Generation %d: best %.6f, average %.6f\n", stats.Generation, -stats.Best, -stats.Avg)
+				}
+				return true
+			},
+		},
+	}
+
+	result, err := engine.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Minimum: %.6f at %.4f\n", -result.Best.Fitness, result.Best.Genome)
+}
+
+// rastrigin Returns the value of the Rastrigin function at x.
+func rastrigin(x []float64) float64 {
+	value := 10 * float64(len(x))
+	for _, xi := range x {
+		value += xi*xi - 10*math.Cos(2*math.Pi*xi)
+	}
+
+	return value
+}
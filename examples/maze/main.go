@@ -0,0 +1,98 @@
+// Command maze evolves the boxes of the main program with the generic engine instead of GeneticBox.
+// A genome is the chain of accelerations of a box, and its fitness is the one of the box after
+// playing it on a level. The population size and the rates are read from genetic_settings.json
+// and the genes are crossed and mutated by population.Box, so the results are comparable with
+// the headless command.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+	"github.com/pipawoz/go_genetic_algorithm/pkg/ga"
+)
+
+func main() {
+	level := flag.Int("level", 1, "level to solve")
+	generations := flag.Int("generations", 200, "number of generations")
+	seed := flag.Int64("seed", 1, "random seed")
+	flag.StringVar(&utils.ConfigDir, "config", utils.ConfigDir, "directory holding genetic_settings.json")
+	flag.Parse()
+
+	settings, err := utils.LoadGeneticSettings()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	moveLimit, walls := utils.LevelLayout(*level)
+
+	result, err := (&ga.Engine[[]utils.Vector]{
+		Config: ga.Config{
+			PopulationSize: settings.PopulationSize,
+			Generations:    *generations,
+			MutationRate:   settings.MutationRate,
+			CrossoverRate:  settings.CrossoverRate,
+			Elitism:        2,
+			Selection:      ga.Roulette(),
+			Rand:           rand.New(rand.NewSource(*seed)),
+		},
+		Evaluate: func(genes []utils.Vector) float64 {
+			return play(genes, walls, moveLimit).Fitness
+		},
+		// Every gene is a unit vector in a random direction, as in population.DNA.
+		Random: func(rng *rand.Rand) []utils.Vector {
+			genes := make([]utils.Vector, population.GeneLength)
+			for i := range genes {
+				angle := rng.Float64() * 2 * math.Pi
+				genes[i] = utils.Vector{X: float32(math.Cos(angle)), Y: float32(math.Sin(angle))}
+			}
+			return genes
+		},
+		Crossover: func(a, b []utils.Vector, rng *rand.Rand) ([]utils.Vector, []utils.Vector) {
+			boxA := population.Box{Genes: population.DNA{Chain: a}}
+			offspringA, offspringB := boxA.CrossoverAt(population.Box{Genes: population.DNA{Chain: b}}, rng.Intn(len(a)-1)+1)
+			return offspringA.Genes.Chain, offspringB.Genes.Chain
+		},
+		// The mutation of population.Box, on a copy of the genes.
+		Mutate: func(genes []utils.Vector, rate float64, rng *rand.Rand) []utils.Vector {
+			if rng.Float64() >= rate {
+				return genes
+			}
+
+			box := population.Box{Genes: population.DNA{Chain: slices.Clone(genes)}}
+			box.MutateGene(rng.Intn(len(genes)-1), rng.Intn(10) > 5)
+			return box.Genes.Chain
+		},
+		Callbacks: ga.Callbacks[[]utils.Vector]{
+			OnGeneration: func(stats ga.Stats, p ga.Population[[]utils.Vector]) bool {
+				fmt.Printf("Generation %d: best %.4f, average %.4f\n", stats.Generation, stats.Best, stats.Avg)
+				return true
+			},
+		},
+	}).Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	box := play(result.Best.Genome, walls, moveLimit)
+	fmt.Printf("Best fitness: %.4f, won: %t, frames: %d\n", box.Fitness, box.Won, box.Frames)
+}
+
+// play Returns the box of the genes after playing the level, with its fitness calculated.
+// The genes are cloned, since a box that wins replaces its remaining genes with no-ops.
+func play(genes []utils.Vector, walls []utils.Obstacle, moveLimit int) *population.Box {
+	box := population.NewBox(&population.DNA{Chain: slices.Clone(genes)})
+	for counter := 0; counter <= moveLimit && box.IsAlive && !box.Won; counter++ {
+		box.Update(counter)
+		box.CheckCollision(walls)
+	}
+	box.CalculateFitness()
+
+	return box
+}
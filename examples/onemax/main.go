@@ -0,0 +1,79 @@
+// Command onemax evolves bit strings until every bit is set, the "hello world" of genetic algorithms.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"slices"
+
+	"github.com/pipawoz/go_genetic_algorithm/pkg/ga"
+)
+
+func main() {
+	length := flag.Int("length", 100, "number of bits")
+	seed := flag.Int64("seed", 1, "random seed")
+	flag.Parse()
+
+	engine := ga.Engine[[]bool]{
+		Config: ga.Config{
+			PopulationSize: 100,
+			Generations:    500,
+			MutationRate:   1 / float64(*length),
+			CrossoverRate:  0.9,
+			Elitism:        2,
+			Selection:      ga.Tournament(3),
+			Rand:           rand.New(rand.NewSource(*seed)),
+		},
+		Evaluate: countOnes,
+		Random: func(rng *rand.Rand) []bool {
+			bits := make([]bool, *length)
+			for i := range bits {
+				bits[i] = rng.Intn(2) == 1
+			}
+			return bits
+		},
+		Crossover: func(a, b []bool, rng *rand.Rand) ([]bool, []bool) {
+			point := rng.Intn(len(a)-1) + 1
+			return slices.Concat(a[:point], b[point:]), slices.Concat(b[:point], a[point:])
+		},
+		// Every bit flips with the given rate.
+		Mutate: func(bits []bool, rate float64, rng *rand.Rand) []bool {
+			bits = slices.Clone(bits)
+			for i := range bits {
+				if rng.Float64() < rate {
+					bits[i] = !bits[i]
+				}
+			}
+			return bits
+		},
+		Callbacks: ga.Callbacks[[]bool]{
+			OnGeneration: func(stats ga.Stats, population ga.Population[[]bool]) bool {
+				return stats.Best < float64(*length)
+			},
+			OnNewBest: func(generation int, best ga.Individual[[]bool]) {
+				fmt.Printf("Generation %d: %.0f/%d bits set\n", generation, best.Fitness, *length)
+			},
+		},
+	}
+
+	result, err := engine.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Best: %.0f/%d bits set after %d generations\n", result.Best.Fitness, *length, len(result.History))
+}
+
+// countOnes Returns the number of set bits.
+func countOnes(bits []bool) float64 {
+	count := 0
+	for _, bit := range bits {
+		if bit {
+			count++
+		}
+	}
+
+	return float64(count)
+}
@@ -0,0 +1,121 @@
+// Command tsp evolves the shortest round trip through random cities, the travelling salesman problem.
+// The genomes are permutations of the cities, bred with order crossover and inversion mutation
+// so every offspring is still a valid tour.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/pipawoz/go_genetic_algorithm/pkg/ga"
+)
+
+// city is a point of the map.
+type city struct {
+	X, Y float64
+}
+
+func main() {
+	count := flag.Int("cities", 30, "number of cities")
+	generations := flag.Int("generations", 1000, "number of generations")
+	seed := flag.Int64("seed", 1, "random seed")
+	flag.Parse()
+
+	rng := rand.New(rand.NewSource(*seed))
+	cities := make([]city, *count)
+	for i := range cities {
+		cities[i] = city{rng.Float64() * 100, rng.Float64() * 100}
+	}
+
+	engine := ga.Engine[[]int]{
+		Config: ga.Config{
+			PopulationSize: 200,
+			Generations:    *generations,
+			MutationRate:   0.2,
+			CrossoverRate:  0.9,
+			Elitism:        4,
+			Selection:      ga.Tournament(5),
+			Rand:           rng,
+		},
+		// Shorter tours are fitter.
+		Evaluate: func(tour []int) float64 {
+			return -tourLength(cities, tour)
+		},
+		Random: func(rng *rand.Rand) []int {
+			return rng.Perm(len(cities))
+		},
+		Crossover: orderCrossover,
+		Mutate: func(tour []int, rate float64, rng *rand.Rand) []int {
+			if rng.Float64() >= rate {
+				return tour
+			}
+
+			// Reversing a section of the tour replaces two of its edges.
+			tour = slices.Clone(tour)
+			i, j := rng.Intn(len(tour)), rng.Intn(len(tour))
+			slices.Reverse(tour[min(i, j) : max(i, j)+1])
+			return tour
+		},
+		Callbacks: ga.Callbacks[[]int]{
+			OnNewBest: func(generation int, best ga.Individual[[]int]) {
+				fmt.Printf("Generation %d: length %.2f\n", generation, -best.Fitness)
+			},
+		},
+	}
+
+	result, err := engine.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Best tour: %v\n", result.Best.Genome)
+	fmt.Printf("Length: %.2f\n", -result.Best.Fitness)
+}
+
+// tourLength Returns the length of the round trip through the cities in the order of the tour.
+func tourLength(cities []city, tour []int) float64 {
+	length := 0.0
+	for i := range tour {
+		from, to := cities[tour[i]], cities[tour[(i+1)%len(tour)]]
+		length += math.Hypot(to.X-from.X, to.Y-from.Y)
+	}
+
+	return length
+}
+
+// orderCrossover Returns the offspring of an order crossover (OX1): each child keeps a random
+// slice of one parent and takes the remaining cities in the order they appear in the other.
+func orderCrossover(a, b []int, rng *rand.Rand) ([]int, []int) {
+	start := rng.Intn(len(a))
+	end := start + rng.Intn(len(a)-start) + 1
+
+	return orderChild(a, b, start, end), orderChild(b, a, start, end)
+}
+
+// orderChild Returns the child with keep[start:end] in place and the other cities in the order of fill.
+func orderChild(keep, fill []int, start, end int) []int {
+	child := make([]int, len(keep))
+	used := make([]bool, len(keep))
+	for i := start; i < end; i++ {
+		child[i] = keep[i]
+		used[keep[i]] = true
+	}
+
+	position := end % len(child)
+	for i := range fill {
+		next := fill[(end+i)%len(fill)]
+		if used[next] {
+			continue
+		}
+
+		child[position] = next
+		used[next] = true
+		position = (position + 1) % len(child)
+	}
+
+	return child
+}
@@ -0,0 +1,253 @@
+// Package dashboard serves a web page and a JSON API to follow and control a run from a browser.
+package dashboard
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/genetics"
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+)
+
+// States of a run.
+const (
+	StateRunning  = "running"
+	StatePaused   = "paused"
+	StateStopped  = "stopped"
+	StateFinished = "finished"
+)
+
+// subscriberBuffer is the number of events kept for a slow client before they are dropped.
+const subscriberBuffer = 16
+
+// Status is the state of the run returned by the API.
+type Status struct {
+	State string `json:"state"`
+	// Generation is the generation being simulated.
+	Generation int `json:"generation"`
+	// Completed is the number of generations evaluated.
+	Completed   int     `json:"completed"`
+	BestFitness float64 `json:"bestFitness"`
+	// Summary is the outcome of the run once it is finished.
+	Summary *genetics.RunSummary `json:"summary,omitempty"`
+}
+
+// Best is the best box found so far.
+type Best struct {
+	Generation int            `json:"generation"`
+	Fitness    float64        `json:"fitness"`
+	Won        bool           `json:"won"`
+	Frames     int            `json:"frames"`
+	Genes      population.DNA `json:"-"`
+}
+
+// Dashboard follows a run as an observer of the evolution loop and controls it as an engine.Controller.
+// The observer methods are called from the game loop and the HTTP handlers from the server,
+// so the state is guarded by a mutex.
+type Dashboard struct {
+	genetics.BaseObserver
+
+	mu          sync.Mutex
+	resumed     *sync.Cond
+	state       string
+	generation  int
+	history     []genetics.GenerationStats
+	best        *Best
+	summary     *genetics.RunSummary
+	subscribers map[chan event]struct{}
+}
+
+// event is a Server-Sent Event.
+type event struct {
+	name string
+	data []byte
+}
+
+// New Creates a dashboard for a run that is about to start.
+func New() *Dashboard {
+	d := &Dashboard{state: StateRunning, subscribers: map[chan event]struct{}{}}
+	d.resumed = sync.NewCond(&d.mu)
+	return d
+}
+
+// OnGenerationStart Records the generation being simulated.
+func (d *Dashboard) OnGenerationStart(generation int, boxes []population.Box) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.generation = generation
+}
+
+// OnGenerationEnd Records the statistics of the generation and sends them to the clients.
+func (d *Dashboard) OnGenerationEnd(stats genetics.GenerationStats) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.history = append(d.history, stats)
+	d.publish("generation", stats)
+}
+
+// OnNewBest Records the best box.
+func (d *Dashboard) OnNewBest(generation int, box *population.Box) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.best = &Best{Generation: generation, Fitness: box.Fitness, Won: box.Won, Frames: box.Frames, Genes: box.Genes.Clone()}
+}
+
+// OnRunFinished Records the outcome of the run.
+func (d *Dashboard) OnRunFinished(summary genetics.RunSummary) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.summary = &summary
+	if d.state != StateStopped {
+		d.state = StateFinished
+	}
+	d.publish("status", d.status())
+}
+
+// Paused Reports whether the run is paused.
+func (d *Dashboard) Paused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.state == StatePaused
+}
+
+// Stopped Reports whether the run was stopped.
+func (d *Dashboard) Stopped() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.state == StateStopped
+}
+
+// Wait Blocks while the run is paused and reports whether it was stopped.
+func (d *Dashboard) Wait() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for d.state == StatePaused {
+		d.resumed.Wait()
+	}
+
+	return d.state == StateStopped
+}
+
+// Pause Pauses a running run.
+func (d *Dashboard) Pause() Status {
+	return d.transition(StateRunning, StatePaused)
+}
+
+// Resume Resumes a paused run.
+func (d *Dashboard) Resume() Status {
+	return d.transition(StatePaused, StateRunning)
+}
+
+// Stop Stops a running or paused run.
+func (d *Dashboard) Stop() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.state == StateRunning || d.state == StatePaused {
+		d.setState(StateStopped)
+	}
+
+	return d.status()
+}
+
+// transition Moves the run to the state to when it is in the state from, and returns its status.
+func (d *Dashboard) transition(from, to string) Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.state == from {
+		d.setState(to)
+	}
+
+	return d.status()
+}
+
+// setState Changes the state, wakes up Wait and tells the clients. d.mu must be held.
+func (d *Dashboard) setState(state string) {
+	d.state = state
+	d.resumed.Broadcast()
+	d.publish("status", d.status())
+}
+
+// Status Returns the state of the run.
+func (d *Dashboard) Status() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.status()
+}
+
+// status Returns the state of the run. d.mu must be held.
+func (d *Dashboard) status() Status {
+	status := Status{State: d.state, Generation: d.generation, Completed: len(d.history), Summary: d.summary}
+	if d.best != nil {
+		status.BestFitness = d.best.Fitness
+	}
+
+	return status
+}
+
+// History Returns the statistics of every generation evaluated so far.
+func (d *Dashboard) History() []genetics.GenerationStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]genetics.GenerationStats(nil), d.history...)
+}
+
+// Best Returns the best box found so far, if any.
+func (d *Dashboard) Best() (Best, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.best == nil {
+		return Best{}, false
+	}
+
+	return *d.best, true
+}
+
+// subscribe Returns a channel receiving the events until unsubscribe is called.
+func (d *Dashboard) subscribe() chan event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	events := make(chan event, subscriberBuffer)
+	d.subscribers[events] = struct{}{}
+	return events
+}
+
+// unsubscribe Stops sending events to the channel.
+func (d *Dashboard) unsubscribe(events chan event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.subscribers, events)
+}
+
+// publish Sends an event to every client. Clients that are too slow miss it rather than
+// slowing down the run. d.mu must be held.
+func (d *Dashboard) publish(name string, value any) {
+	if len(d.subscribers) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	for events := range d.subscribers {
+		select {
+		case events <- event{name, data}:
+		default:
+		}
+	}
+}
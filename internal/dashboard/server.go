@@ -0,0 +1,157 @@
+package dashboard
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+)
+
+//go:embed index.html
+var indexPage []byte
+
+// Handler Returns the handler of the web page and the API:
+//
+//	GET  /                 the web page
+//	GET  /api/status       the state of the run
+//	GET  /api/history      the statistics of every generation
+//	GET  /api/best         the best box found so far
+//	GET  /api/best/genome  its genome, in the JSON format read by the replay command
+//	GET  /api/events       Server-Sent Events: "generation" with the statistics, "status" with the state
+//	POST /api/pause        pause the run
+//	POST /api/resume       resume the run
+//	POST /api/stop         stop the run; the results are still exported
+//
+// The POST requests must have a JSON content type, see control.
+func (d *Dashboard) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(indexPage)
+	})
+	mux.HandleFunc("GET /api/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, d.Status())
+	})
+	mux.HandleFunc("GET /api/history", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, d.History())
+	})
+	mux.HandleFunc("GET /api/best", func(w http.ResponseWriter, r *http.Request) {
+		if best, ok := d.Best(); ok {
+			writeJSON(w, best)
+		} else {
+			http.Error(w, "no generation has been evaluated yet", http.StatusNotFound)
+		}
+	})
+	mux.HandleFunc("GET /api/best/genome", func(w http.ResponseWriter, r *http.Request) {
+		best, ok := d.Best()
+		if !ok {
+			http.Error(w, "no generation has been evaluated yet", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="best_genome.json"`)
+		population.WriteGenomesJSON(w, []population.DNA{best.Genes})
+	})
+	mux.HandleFunc("GET /api/events", d.serveEvents)
+	mux.HandleFunc("POST /api/pause", control(d.Pause))
+	mux.HandleFunc("POST /api/resume", control(d.Resume))
+	mux.HandleFunc("POST /api/stop", control(d.Stop))
+
+	return mux
+}
+
+// control Returns the handler of a POST request controlling the run, which writes the status.
+// Any web page could send a bare POST to the dashboard, so the request must have a JSON
+// content type, which browsers only send cross-origin after a preflight the dashboard does
+// not answer, and an Origin header, when there is one, must be the dashboard itself.
+func control(action func() Status) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+				http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+				return
+			}
+		}
+
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			http.Error(w, "the content type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		writeJSON(w, action())
+	}
+}
+
+// Start Starts serving the dashboard on addr in the background.
+// The address is checked before returning; close the server to stop it.
+func (d *Dashboard) Start(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &http.Server{Handler: d.Handler()}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			slog.Error("dashboard stopped", "err", err)
+		}
+	}()
+
+	slog.Info("dashboard started", "url", "http://"+listener.Addr().String())
+	return server, nil
+}
+
+// serveEvents Streams the events of the run until the client goes away.
+// The current status is sent first so the page does not wait for the next event.
+func (d *Dashboard) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events := d.subscribe()
+	defer d.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	status, _ := json.Marshal(d.Status())
+	writeEvent(w, event{"status", status})
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-events:
+			writeEvent(w, e)
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent Writes a Server-Sent Event.
+func writeEvent(w http.ResponseWriter, e event) {
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.name, e.data)
+}
+
+// writeJSON Writes value as the JSON body of the response.
+func writeJSON(w http.ResponseWriter, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// Chart panel geometry, at the bottom left of the window.
+const (
+	chartX         = 10
+	chartY         = utils.GameHeight - 230
+	chartWidth     = 420
+	chartHeight    = 200
+	histogramX     = chartX + chartWidth + 20
+	histogramWidth = 260
+	histogramBins  = 20
+)
+
+// Colors of the fitness series.
+var (
+	bestColor  = color.RGBA{80, 220, 80, 255}
+	avgColor   = color.RGBA{240, 200, 40, 255}
+	worstColor = color.RGBA{230, 70, 70, 255}
+)
+
+// drawCharts Draws the fitness history, the fitness histogram of the current population
+// and the alive/dead/won counts. The panel is toggled with the C key.
+func (g *Game) drawCharts(screen *ebiten.Image) {
+	background := color.RGBA{20, 20, 20, 200}
+	vector.DrawFilledRect(screen, chartX-5, chartY-20, chartWidth+10, chartHeight+40, background, false)
+	vector.DrawFilledRect(screen, histogramX-5, chartY-20, histogramWidth+10, chartHeight+40, background, false)
+
+	history := g.geneticAlgorithm.History
+	best := make([]float64, len(history))
+	avg := make([]float64, len(history))
+	worst := make([]float64, len(history))
+	for i, stats := range history {
+		best[i], avg[i], worst[i] = stats.BestFitness, stats.AvgFitness, stats.WorstFitness
+	}
+
+	ebitenutil.DebugPrintAt(screen, "Fitness: best / avg / worst", chartX, chartY-18)
+	drawLineChart(screen, chartX, chartY, chartWidth, chartHeight,
+		[][]float64{worst, avg, best}, []color.RGBA{worstColor, avgColor, bestColor})
+
+	var fitness []float64
+	alive, dead, won := 0, 0, 0
+	for _, individual := range g.geneticAlgorithm.Population {
+		// CalculateFitness is run on a copy so the state of the generation is untouched.
+		individual.CalculateFitness()
+		fitness = append(fitness, individual.Fitness)
+
+		switch {
+		case individual.Won:
+			won++
+		case individual.IsAlive:
+			alive++
+		default:
+			dead++
+		}
+	}
+
+	counts := fmt.Sprintf("Alive: %d  Dead: %d  Won: %d", alive, dead, won)
+	ebitenutil.DebugPrintAt(screen, counts, histogramX, chartY-18)
+	drawHistogram(screen, histogramX, chartY, histogramWidth, chartHeight, fitness)
+}
+
+// drawLineChart Draws the series as lines scaled to the same range.
+func drawLineChart(screen *ebiten.Image, x, y, width, height float32, series [][]float64, colors []color.RGBA) {
+	low, high := seriesRange(series)
+
+	for s, values := range series {
+		if len(values) < 2 {
+			continue
+		}
+
+		for i := 1; i < len(values); i++ {
+			x0 := x + width*float32(i-1)/float32(len(values)-1)
+			x1 := x + width*float32(i)/float32(len(values)-1)
+			y0 := y + height*float32(1-(values[i-1]-low)/(high-low))
+			y1 := y + height*float32(1-(values[i]-low)/(high-low))
+			vector.StrokeLine(screen, x0, y0, x1, y1, 1, colors[s], false)
+		}
+	}
+
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%.2f", high), int(x), int(y))
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%.2f", low), int(x), int(y+height)-16)
+}
+
+// drawHistogram Draws the distribution of the values in histogramBins bars.
+func drawHistogram(screen *ebiten.Image, x, y, width, height float32, values []float64) {
+	if len(values) == 0 {
+		return
+	}
+
+	low, high := seriesRange([][]float64{values})
+
+	bins := make([]int, histogramBins)
+	peak := 0
+	for _, value := range values {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+
+		bin := min(int((value-low)/(high-low)*histogramBins), histogramBins-1)
+		bins[bin]++
+		peak = max(peak, bins[bin])
+	}
+
+	if peak == 0 {
+		return
+	}
+
+	barWidth := width / histogramBins
+	for i, count := range bins {
+		barHeight := height * float32(count) / float32(peak)
+		vector.DrawFilledRect(screen, x+float32(i)*barWidth, y+height-barHeight, barWidth-1, barHeight, avgColor, false)
+	}
+
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%.2f", low), int(x), int(y+height))
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%.2f", high), int(x+width)-30, int(y+height))
+}
+
+// seriesRange Returns the lowest and highest finite values of the series.
+// The range is widened when it is empty so values can always be scaled.
+func seriesRange(series [][]float64) (float64, float64) {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, values := range series {
+		for _, value := range values {
+			if math.IsInf(value, 0) || math.IsNaN(value) {
+				continue
+			}
+			low, high = math.Min(low, value), math.Max(high, value)
+		}
+	}
+
+	if math.IsInf(low, 0) {
+		return 0, 1
+	}
+
+	if high-low < 1e-9 {
+		return low - 0.5, high + 0.5
+	}
+
+	return low, high
+}
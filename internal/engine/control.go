@@ -0,0 +1,23 @@
+package engine
+
+import "errors"
+
+// ErrStopped is returned by Update once the run has been stopped by its controller.
+var ErrStopped = errors.New("run stopped")
+
+// Controller pauses and stops a run from outside the game loop, e.g. from the dashboard.
+// Its methods are called from the game loop and must be safe to call from any goroutine.
+type Controller interface {
+	// Paused Reports whether the run is paused.
+	Paused() bool
+	// Stopped Reports whether the run must stop.
+	Stopped() bool
+	// Wait Blocks while the run is paused and reports whether it must stop.
+	Wait() bool
+}
+
+// SetController Lets controller pause and stop the run. The window pauses between frames;
+// headless runs pause and stop between generations.
+func (g *Game) SetController(controller Controller) {
+	g.controller = controller
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/genetics"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// curriculum tracks the progress of a run through the stages of the curriculum setting.
+type curriculum struct {
+	stages []utils.CurriculumStage
+	stage  int
+	// generations is the number of generations evolved in the current stage.
+	generations int
+}
+
+// newCurriculum Returns the curriculum set in the settings, or nil when there is none.
+func newCurriculum() *curriculum {
+	if len(utils.Settings.Curriculum) == 0 {
+		return nil
+	}
+
+	return &curriculum{stages: utils.Settings.Curriculum}
+}
+
+// advanceCurriculum Moves the run to the next stage once the generation that just ended
+// meets the win rate of the current stage, or the stage used all its generations.
+// The population is carried forward to the level of the next stage.
+// After the last stage the run ends with the current generation.
+func (g *Game) advanceCurriculum(stats genetics.GenerationStats) {
+	c := g.curriculum
+	if c == nil {
+		return
+	}
+
+	c.generations++
+	stage := c.stages[c.stage]
+
+	passed := stats.WinRate() >= stage.WinRate
+	if !passed && (stage.MaxGenerations == 0 || c.generations < stage.MaxGenerations) {
+		return
+	}
+
+	attrs := []any{"stage", c.stage + 1, "stages", len(c.stages), "level", stage.Level,
+		"generations", c.generations, "winRate", stats.WinRate()}
+	if passed {
+		slog.Info("curriculum stage passed", attrs...)
+	} else {
+		slog.Warn("curriculum stage stopped below its win rate", append(attrs, "requiredWinRate", stage.WinRate)...)
+	}
+
+	if c.stage == len(c.stages)-1 {
+		g.maxGenerations = g.currentGeneration
+		return
+	}
+
+	// The results of every level are exported apart, so the level in the file names stays right.
+	g.saveHeatmap()
+	g.heatmap = NewHeatmap()
+	g.heatmapDirty = true
+	g.ghost = nil
+	g.geneticAlgorithm.ResetBest()
+
+	c.stage++
+	c.generations = 0
+	g.SelectLevel(c.stages[c.stage].Level)
+}
+
+// curriculumLabel Returns the stage shown in the window, or an empty string without a curriculum.
+func (g *Game) curriculumLabel() string {
+	c := g.curriculum
+	if c == nil {
+		return ""
+	}
+
+	stage := c.stages[c.stage]
+	return fmt.Sprintf("Stage %d/%d: level %d, win rate %.0f%%", c.stage+1, len(c.stages), stage.Level, stage.WinRate*100)
+}
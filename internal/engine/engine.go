@@ -1,15 +1,38 @@
 package engine
 
 import (
+	"errors"
 	"fmt"
 	"image/color"
+	"log/slog"
+	"path/filepath"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 	"github.com/pipawoz/go_genetic_algorithm/internal/genetics"
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/internal/render"
 	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
 )
 
+// islandColors are the colors of the boxes of every island when there are several.
+var islandColors = []color.RGBA{
+	{255, 0, 0, 255},
+	{60, 140, 255, 255},
+	{255, 200, 0, 255},
+	{200, 80, 255, 255},
+	{0, 220, 220, 255},
+	{255, 130, 40, 255},
+	{255, 120, 200, 255},
+	{150, 255, 120, 255},
+}
+
+// ErrMaxGenerations is returned by Update once the last generation has been simulated.
+var ErrMaxGenerations = errors.New("max generations reached")
+
 // Game represents the game state.
 type Game struct {
 	geneticAlgorithm  *genetics.GeneticBox
@@ -21,30 +44,89 @@ type Game struct {
 	level             int
 	showTrails        bool
 	trailImage        *ebiten.Image
-	avgFitness        float64
-	avgFitnessOld     float64
-	fitnessHistory    []float64
+	fadeImage         *ebiten.Image
+	trailMode         string
+	paintedFrame      trailFrame
+	showGhost         bool
+	ghost             []TrajectoryFrame
+	headless          bool
+	showPanel         bool
+	panelIndex        int
+	paramLog          []ParamChange
+	showCharts        bool
+	heatmap           *Heatmap
+	heatmapMode       int
+	heatmapOverlay    *ebiten.Image
+	heatmapDirty      bool
+	finished          bool
+	curriculum        *curriculum
+	controller        Controller
+	recordGeneration  int
+	recorder          *Recorder
+	renderer          ebitenRenderer
 }
 
-// NewGame Creates a new game.
-func NewGame(populationSize int, maxGenerations int, showTrails bool) *Game {
+// NewGame Creates a new game. The initial population is seeded with seeds when given.
+// With a curriculum, the game starts on the level of its first stage instead of currentLevel.
+// The statistics are logged and the output files are written by observers of the evolution loop.
+func NewGame(populationSize int, maxGenerations int, showTrails bool, seeds []population.DNA) *Game {
 	game := &Game{
-		geneticAlgorithm:  genetics.NewGeneticBox(populationSize),
+		geneticAlgorithm:  genetics.NewSeededGeneticBox(populationSize, seeds),
 		currentGeneration: 1,
 		maxGenerations:    maxGenerations,
 		counter:           0,
 		level:             utils.Settings.CurrentLevel,
 		showTrails:        showTrails,
-		trailImage:        ebiten.NewImage(utils.GameWidth, utils.GameHeight),
+		trailMode:         utils.Settings.TrailMode,
+		showGhost:         utils.Settings.GhostTrail,
+		heatmap:           NewHeatmap(),
+		curriculum:        newCurriculum(),
+	}
+	if game.curriculum != nil {
+		game.level = game.curriculum.stages[0].Level
+	}
+	if game.trailMode == "" {
+		game.trailMode = utils.TrailSolid
 	}
 	game.moveLimit, game.walls = game.SelectLevel(game.level)
+	if utils.Settings.RecordFile != "" {
+		game.recordGeneration = utils.Settings.RecordGeneration
+		if game.recordGeneration == 0 {
+			game.recordGeneration = maxGenerations
+		}
+	}
+	game.AddObserver(&runLogger{game: game}, &statsWriter{game: game}, &genomeSaver{game: game}, &paretoWriter{game: game},
+		&snapshotWriter{game: game}, &ghostTracker{game: game})
 	return game
 }
 
 // Update Updates the game state.
 func (g *Game) Update() error {
 	if g.currentGeneration > g.maxGenerations {
-		return fmt.Errorf("Max generations reached")
+		g.finishRun()
+		return ErrMaxGenerations
+	}
+
+	if g.controller != nil && g.controller.Stopped() {
+		g.finishRun()
+		return ErrStopped
+	}
+
+	if !g.headless {
+		g.handleTuningInput()
+		g.handleKeys()
+		g.handleTrailKeys()
+	}
+
+	if g.controller != nil && g.controller.Paused() {
+		return nil
+	}
+
+	if g.counter == 0 {
+		g.geneticAlgorithm.StartGeneration()
+		if g.currentGeneration == g.recordGeneration {
+			g.recorder = NewRecorder(g.levelPath(utils.Settings.RecordFile), utils.Settings.RecordFrameStep)
+		}
 	}
 
 	allDeadOrWon := true
@@ -57,75 +139,154 @@ func (g *Game) Update() error {
 		}
 	}
 
+	// The last frame of the generation is always recorded.
+	if g.recorder != nil && (g.recorder.Due(g.counter) || allDeadOrWon || g.counter >= g.moveLimit) {
+		if err := g.recorder.Capture(g.Draw); err != nil {
+			slog.Error("could not record frame", "err", err)
+			g.recorder = nil
+		}
+	}
+
 	g.counter++
 
 	if allDeadOrWon || g.counter > g.moveLimit {
-		g.geneticAlgorithm.NextGeneration()
-
-		avgFitnessCurrent := g.geneticAlgorithm.AvgFitness
-		avgDistance := g.geneticAlgorithm.AvgDistance
+		g.endGeneration()
+	}
 
-		fmt.Println("")
-		fmt.Println("*** Generation ***")
-		fmt.Println("Generation: ", g.currentGeneration)
-		fmt.Println("Avg Distance: ", avgDistance)
-		fmt.Println("Avg Fitness: ", avgFitnessCurrent)
+	return nil
+}
 
-		if g.currentGeneration > 1 {
-			percentageChange := ((avgFitnessCurrent - g.avgFitnessOld) / g.avgFitnessOld) * 100
-			fmt.Printf("Avg Fitness Change: %.2f%%\n", percentageChange)
+// endGeneration Records the generation that just finished and breeds the next one.
+// The observers of the evolution loop are notified during NextGeneration.
+func (g *Game) endGeneration() {
+	// Dead boxes stop moving, so their position is where they died.
+	for i := range g.geneticAlgorithm.Population {
+		individual := &g.geneticAlgorithm.Population[i]
+		if individual.IsAlive || individual.Won {
+			g.heatmap.AddSurvivor(individual.Position)
+		} else {
+			g.heatmap.AddDeath(individual.Position)
 		}
+	}
+	g.heatmap.EndGeneration()
+	g.heatmapDirty = true
+	g.saveRecording()
+
+	g.geneticAlgorithm.NextGeneration()
 
-		g.avgFitnessOld = g.avgFitness
-		g.avgFitness = avgFitnessCurrent
-		g.fitnessHistory = append(g.fitnessHistory, avgFitnessCurrent)
+	g.advanceCurriculum(g.geneticAlgorithm.History[len(g.geneticAlgorithm.History)-1])
 
-		g.counter = 0
-		g.currentGeneration++
+	g.counter = 0
+	g.currentGeneration++
+	if g.trailImage != nil {
 		g.trailImage.Clear()
 	}
+}
 
-	return nil
+// handleKeys Handles the keys that toggle the overlays.
+// C toggles the charts, H cycles the heatmap modes and P exports the heatmap shown.
+func (g *Game) handleKeys() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		g.showCharts = !g.showCharts
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyH) {
+		g.heatmapMode = (g.heatmapMode + 1) % heatmapModes
+		g.heatmapDirty = true
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		cumulative := g.heatmapMode != heatmapGeneration
+		path := fmt.Sprintf("heatmap_level_%d_gen_%d.png", g.level, g.currentGeneration-1)
+		if err := g.heatmap.SavePNG(path, cumulative); err != nil {
+			slog.Error("could not save heatmap", "err", err)
+		} else {
+			slog.Info("heatmap saved", "path", path)
+		}
+	}
 }
 
-// Draw Draws the game state.
-func (g *Game) Draw(screen *ebiten.Image) {
+// finishRun Runs once after the last generation, exporting the results of the run.
+func (g *Game) finishRun() {
+	if g.finished {
+		return
+	}
+	g.finished = true
 
-	if !g.showTrails {
-		g.trailImage.Clear()
+	g.saveHeatmap()
+	g.saveRecording()
+
+	if utils.Settings.GenealogyFile != "" {
+		if err := g.saveGenealogy(); err != nil {
+			slog.Error("could not save genealogy", "err", err)
+		}
 	}
 
-	screen.Fill(color.RGBA{0, 0, 0, 255})
+	g.geneticAlgorithm.Finish()
+}
 
-	var goalSize = 40
-	var goalX = utils.GameWidth - goalSize - 10
-	var goalY = utils.GameHeight/2 - goalSize/2
+// saveRecording Writes the recording of the generation, if one is in progress.
+// A run stopped in the middle of the recorded generation keeps the frames recorded so far.
+func (g *Game) saveRecording() {
+	if g.recorder == nil {
+		return
+	}
+
+	if err := g.recorder.Close(); err != nil {
+		slog.Error("could not save recording", "err", err)
+	} else {
+		slog.Info("recording saved", "path", g.levelPath(utils.Settings.RecordFile), "generation", g.currentGeneration, "frames", g.recorder.Frames())
+	}
 
-	// Draw goal
-	goalImg := ebiten.NewImage(goalSize, goalSize)
-	goalImg.Fill(color.RGBA{0, 255, 0, 255})
-	goalOpts := &ebiten.DrawImageOptions{}
-	goalOpts.GeoM.Translate(float64(goalX), float64(goalY))
-	screen.DrawImage(goalImg, goalOpts)
+	g.recorder = nil
+}
 
-	// Draw walls
-	for _, wall := range g.walls {
-		wallImg := ebiten.NewImage(wall.Width, wall.Height)
-		wallImg.Fill(color.RGBA{255, 255, 255, 255})
-		wallOpts := &ebiten.DrawImageOptions{}
-		wallOpts.GeoM.Translate(float64(wall.X), float64(wall.Y))
-		screen.DrawImage(wallImg, wallOpts)
+// saveHeatmap Exports the cumulative heatmap of the level to the file set in heatmapFile, if any.
+func (g *Game) saveHeatmap() {
+	if utils.Settings.HeatmapFile == "" {
+		return
 	}
 
-	// Draw individuals
-	for i := range g.geneticAlgorithm.Population {
-		individual := &g.geneticAlgorithm.Population[i]
-		if individual.IsAlive {
-			individual.Draw(g.trailImage)
+	if err := g.heatmap.SavePNG(g.levelPath(utils.Settings.HeatmapFile), true); err != nil {
+		slog.Error("could not save heatmap", "err", err)
+	}
+}
+
+// saveGenealogy Exports the genealogy of the run. JSON files hold every individual;
+// DOT graphs would be unreadable that way, so they hold the ancestry of the best individual.
+func (g *Game) saveGenealogy() error {
+	genealogy := g.geneticAlgorithm.Genealogy
+	path := g.levelPath(utils.Settings.GenealogyFile)
+
+	if strings.EqualFold(filepath.Ext(path), ".dot") {
+		if best, ok := genealogy.Best(); ok {
+			return genealogy.Save(path, best.ID)
 		}
 	}
 
-	screen.DrawImage(g.trailImage, nil)
+	return genealogy.Save(path)
+}
+
+// Draw Draws the game state.
+func (g *Game) Draw(screen *ebiten.Image) {
+	scene := g.renderer.begin(screen)
+	drawScene(scene, g.walls)
+	scene.Flush()
+
+	if g.heatmapMode != heatmapOff {
+		if g.heatmapOverlay == nil || g.heatmapDirty {
+			if g.heatmapOverlay != nil {
+				g.heatmapOverlay.Deallocate()
+			}
+			g.heatmapOverlay = ebiten.NewImageFromImage(g.heatmap.Image(g.heatmapMode == heatmapCumulative))
+			g.heatmapDirty = false
+		}
+		screen.DrawImage(g.heatmapOverlay, nil)
+	}
+
+	// Draw individuals and their trails. The trail image is created on the first draw,
+	// so headless runs, which never draw, do not allocate it.
+	g.drawBoxes(screen)
 
 	// Draw information
 	msg := fmt.Sprintf("Generación: %d/%d", g.currentGeneration, g.maxGenerations)
@@ -134,6 +295,65 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	frameMsg := fmt.Sprintf("Frame: %d", g.counter)
 	ebitenutil.DebugPrintAt(screen, frameMsg, 10, 30)
 
+	if label := g.curriculumLabel(); label != "" {
+		ebitenutil.DebugPrintAt(screen, label, 10, 90)
+	}
+
+	if g.trailMode != utils.TrailSolid || g.showGhost {
+		ebitenutil.DebugPrintAt(screen, g.trailLabel(), 10, 110)
+	}
+
+	islands := len(g.geneticAlgorithm.Islands)
+	if islands > 1 {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Islands: %d", islands), 10, 70)
+		for k := 0; k < islands; k++ {
+			vector.DrawFilledRect(screen, float32(80+12*k), 74, 8, 8, islandColors[k%len(islandColors)], false)
+		}
+	}
+
+	switch g.heatmapMode {
+	case heatmapGeneration:
+		ebitenutil.DebugPrintAt(screen, "Heatmap: last generation", 10, 50)
+	case heatmapCumulative:
+		ebitenutil.DebugPrintAt(screen, "Heatmap: cumulative", 10, 50)
+	}
+
+	if g.showCharts {
+		g.drawCharts(screen)
+	}
+
+	if g.showPanel {
+		g.drawTuningPanel(screen)
+	}
+
+}
+
+// drawScene Draws the background, the goal area and the walls of the level.
+func drawScene(r render.Renderer, walls []utils.Obstacle) {
+	r.Fill(color.RGBA{0, 0, 0, 255})
+	drawGoal(r)
+	drawWalls(r, walls)
+}
+
+// drawGoal Draws the goal area.
+func drawGoal(r render.Renderer) {
+	r.FillRect(utils.GoalX, utils.GoalY, utils.GoalSize, utils.GoalSize, color.RGBA{0, 255, 0, 255})
+}
+
+// drawWalls Draws the walls of the level.
+func drawWalls(r render.Renderer, walls []utils.Obstacle) {
+	for _, wall := range walls {
+		r.FillRect(float32(wall.X), float32(wall.Y), float32(wall.Width), float32(wall.Height), color.RGBA{255, 255, 255, 255})
+	}
+}
+
+// drawBox Draws a box, colored by island when there are several.
+func drawBox(r render.Renderer, box *population.Box, islands int) {
+	if islands > 1 {
+		box.DrawColor(r, islandColors[box.Island%len(islandColors)])
+	} else {
+		box.Draw(r)
+	}
 }
 
 // Layout Layout the game.
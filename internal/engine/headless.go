@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"log/slog"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/genetics"
+)
+
+// RunHeadless Runs the evolution without a window until the last generation,
+// or until the controller stops it. The results are exported either way.
+// Every generation is simulated at once, each island in its own goroutine.
+func (g *Game) RunHeadless() error {
+	g.headless = true
+
+	for g.currentGeneration <= g.maxGenerations {
+		if g.controller != nil && g.controller.Wait() {
+			break
+		}
+
+		g.geneticAlgorithm.StartGeneration()
+		g.geneticAlgorithm.Simulate(g.walls, g.moveLimit, true)
+		if g.currentGeneration == g.recordGeneration {
+			if err := g.recordRollout(); err != nil {
+				slog.Error("could not save recording", "err", err)
+			}
+		}
+		g.endGeneration()
+	}
+
+	g.finishRun()
+	return nil
+}
+
+// Generation Returns the generation currently being simulated.
+func (g *Game) Generation() int {
+	return g.currentGeneration
+}
+
+// Frame Returns the frame of the current generation.
+func (g *Game) Frame() int {
+	return g.counter
+}
+
+// Summary Returns the outcome of the run.
+func (g *Game) Summary() genetics.RunSummary {
+	return g.geneticAlgorithm.Summary()
+}
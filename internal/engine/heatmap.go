@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// heatmapCell is the size in pixels of a heatmap cell.
+const heatmapCell = 8
+
+// Heatmap modes, cycled with the H key.
+const (
+	heatmapOff = iota
+	heatmapGeneration
+	heatmapCumulative
+	heatmapModes
+)
+
+// heatmapLayer counts events per cell.
+type heatmapLayer []float64
+
+// Heatmap accumulates where boxes die and where the survivors end.
+// The current generation is accumulated apart and merged into the cumulative
+// layers when it ends, so both the last generation and the whole run can be shown.
+type Heatmap struct {
+	cols, rows          int
+	deaths, survivors   heatmapLayer
+	lastDeaths          heatmapLayer
+	lastSurvivors       heatmapLayer
+	cumulativeDeaths    heatmapLayer
+	cumulativeSurvivors heatmapLayer
+}
+
+// NewHeatmap Creates an empty heatmap covering the arena.
+func NewHeatmap() *Heatmap {
+	cols := (utils.GameWidth + heatmapCell - 1) / heatmapCell
+	rows := (utils.GameHeight + heatmapCell - 1) / heatmapCell
+
+	layer := func() heatmapLayer { return make(heatmapLayer, cols*rows) }
+
+	return &Heatmap{
+		cols:                cols,
+		rows:                rows,
+		deaths:              layer(),
+		survivors:           layer(),
+		lastDeaths:          layer(),
+		lastSurvivors:       layer(),
+		cumulativeDeaths:    layer(),
+		cumulativeSurvivors: layer(),
+	}
+}
+
+// AddDeath Records a box that died at the given position.
+func (h *Heatmap) AddDeath(position utils.Vector) {
+	h.deaths[h.cell(position)]++
+}
+
+// AddSurvivor Records the final position of a box that survived the generation.
+func (h *Heatmap) AddSurvivor(position utils.Vector) {
+	h.survivors[h.cell(position)]++
+}
+
+// EndGeneration Merges the current generation into the cumulative layers and starts a new one.
+func (h *Heatmap) EndGeneration() {
+	for i := range h.deaths {
+		h.cumulativeDeaths[i] += h.deaths[i]
+		h.cumulativeSurvivors[i] += h.survivors[i]
+	}
+
+	h.lastDeaths, h.deaths = h.deaths, h.lastDeaths
+	h.lastSurvivors, h.survivors = h.survivors, h.lastSurvivors
+	clear(h.deaths)
+	clear(h.survivors)
+}
+
+// cell Returns the index of the cell holding the position, clamped to the arena.
+func (h *Heatmap) cell(position utils.Vector) int {
+	col := min(max(int(position.X)/heatmapCell, 0), h.cols-1)
+	row := min(max(int(position.Y)/heatmapCell, 0), h.rows-1)
+	return row*h.cols + col
+}
+
+// Image Renders the last generation, or the whole run when cumulative is set.
+// Deaths go from dark red to yellow and survivors are blue; both use a logarithmic scale.
+func (h *Heatmap) Image(cumulative bool) *image.RGBA {
+	deaths, survivors := h.lastDeaths, h.lastSurvivors
+	if cumulative {
+		deaths, survivors = h.cumulativeDeaths, h.cumulativeSurvivors
+	}
+
+	maxDeaths, maxSurvivors := 0.0, 0.0
+	for i := range deaths {
+		maxDeaths = math.Max(maxDeaths, deaths[i])
+		maxSurvivors = math.Max(maxSurvivors, survivors[i])
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, utils.GameWidth, utils.GameHeight))
+	for row := 0; row < h.rows; row++ {
+		for col := 0; col < h.cols; col++ {
+			i := row*h.cols + col
+			death := logScale(deaths[i], maxDeaths)
+			survivor := logScale(survivors[i], maxSurvivors)
+			if death == 0 && survivor == 0 {
+				continue
+			}
+
+			clr := color.RGBA{
+				R: uint8(255 * death),
+				G: uint8(255 * death * death),
+				B: uint8(255 * survivor),
+				A: uint8(255 * math.Max(0.35+0.5*death, 0.35+0.5*survivor)),
+			}
+
+			rect := image.Rect(col*heatmapCell, row*heatmapCell, (col+1)*heatmapCell, (row+1)*heatmapCell)
+			fillRect(img, rect.Intersect(img.Bounds()), premultiply(clr))
+		}
+	}
+
+	return img
+}
+
+// SavePNG Writes the heatmap to a PNG file.
+func (h *Heatmap) SavePNG(path string, cumulative bool) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(file, h.Image(cumulative)); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// logScale Maps a count to [0, 1] relative to the highest count.
+func logScale(value, highest float64) float64 {
+	if value <= 0 || highest <= 0 {
+		return 0
+	}
+
+	return math.Log1p(value) / math.Log1p(highest)
+}
+
+// premultiply Converts a color to the alpha-premultiplied form image.RGBA stores.
+func premultiply(clr color.RGBA) color.RGBA {
+	alpha := uint16(clr.A)
+	return color.RGBA{
+		R: uint8(uint16(clr.R) * alpha / 255),
+		G: uint8(uint16(clr.G) * alpha / 255),
+		B: uint8(uint16(clr.B) * alpha / 255),
+		A: clr.A,
+	}
+}
+
+// fillRect Fills a rectangle of the image with a color.
+func fillRect(img *image.RGBA, rect image.Rectangle, clr color.RGBA) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetRGBA(x, y, clr)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package engine
+
+import (
+	"context"
+	"encoding/csv"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/genetics"
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// AddObserver Registers observers of the evolution loop, after the ones of the game.
+func (g *Game) AddObserver(observers ...genetics.Observer) {
+	g.geneticAlgorithm.AddObserver(observers...)
+}
+
+// levelPath Returns the path of an output file, with {} replaced by the current level.
+func (g *Game) levelPath(path string) string {
+	return levelPath(path, g.level)
+}
+
+// levelPath Returns the path of an output file, with {} replaced by the level.
+func levelPath(path string, level int) string {
+	return strings.ReplaceAll(path, "{}", strconv.Itoa(level))
+}
+
+// runLogger Logs a record with the statistics of every generation, the new best boxes and the
+// outcome of the run. The wins and the deaths are logged at debug level.
+type runLogger struct {
+	genetics.BaseObserver
+	game *Game
+	// previousAvg is the average fitness of the previous generation, if any.
+	previousAvg float64
+	hasPrevious bool
+}
+
+// OnGenerationEnd Logs the statistics of the generation.
+// The change of the average fitness is left out when the previous average is 0.
+func (l *runLogger) OnGenerationEnd(stats genetics.GenerationStats) {
+	attrs := []slog.Attr{
+		slog.Int("generation", stats.Generation),
+		slog.Int("level", l.game.level),
+		slog.Float64("bestFitness", stats.BestFitness),
+		slog.Float64("avgFitness", stats.AvgFitness),
+		slog.Float64("worstFitness", stats.WorstFitness),
+		slog.Int("avgDistance", stats.AvgDistance),
+		slog.Int("alive", stats.Alive),
+		slog.Int("dead", stats.Dead),
+		slog.Int("won", stats.Won),
+		slog.Float64("winRate", stats.WinRate()),
+	}
+
+	if l.hasPrevious && l.previousAvg != 0 {
+		attrs = append(attrs, slog.Float64("avgFitnessChange", (stats.AvgFitness-l.previousAvg)/math.Abs(l.previousAvg)*100))
+	}
+	l.previousAvg, l.hasPrevious = stats.AvgFitness, true
+
+	if mode := utils.DNASettings.FitnessMode; mode == utils.FitnessNovelty || mode == utils.FitnessHybrid {
+		attrs = append(attrs, slog.Float64("avgNovelty", stats.AvgNovelty), slog.Int("archiveSize", stats.ArchiveSize))
+	}
+
+	if utils.DNASettings.Algorithm == utils.AlgorithmNSGA2 {
+		attrs = append(attrs, slog.Int("paretoFront", len(l.game.geneticAlgorithm.ParetoFront)))
+	}
+
+	if utils.DNASettings.Algorithm == utils.AlgorithmSpecies {
+		attrs = append(attrs, slog.Int("species", stats.Species))
+	}
+
+	if stats.IslandBest != nil {
+		attrs = append(attrs, slog.Any("islandBest", stats.IslandBest), slog.Int("migrants", stats.Migrants))
+	}
+
+	diversity := stats.Diversity
+	attrs = append(attrs, slog.Group("diversity",
+		slog.Float64("genomeDistance", diversity.GenomeDistance),
+		slog.Float64("positionalSpread", diversity.PositionalSpread),
+		slog.Int("clusters", diversity.Clusters),
+		slog.Float64("geneEntropy", diversity.GeneEntropy),
+	))
+
+	slog.LogAttrs(context.Background(), slog.LevelInfo, "generation", attrs...)
+}
+
+// OnWin Logs the box at debug level.
+func (l *runLogger) OnWin(generation int, box *population.Box) {
+	slog.Debug("box won", "generation", generation, "id", box.Lineage.ID, "frames", box.Frames)
+}
+
+// OnDeath Logs the box at debug level.
+func (l *runLogger) OnDeath(generation int, box *population.Box, cause population.DeathCause, position utils.Vector) {
+	slog.Debug("box died", "generation", generation, "id", box.Lineage.ID, "cause", string(cause),
+		"x", position.X, "y", position.Y)
+}
+
+// OnNewBest Logs the fitness of the box.
+func (l *runLogger) OnNewBest(generation int, box *population.Box) {
+	slog.Info("new best", "generation", generation, "level", l.game.level, "fitness", box.Fitness, "won", box.Won)
+}
+
+// OnRunFinished Logs the outcome of the run.
+func (l *runLogger) OnRunFinished(summary genetics.RunSummary) {
+	slog.Info("run finished", "generations", summary.Generations, "firstWin", summary.FirstWin,
+		"bestFitness", summary.BestFitness, "winRate", summary.WinRate)
+}
+
+// statsWriter Appends the statistics of every generation to the CSV file set in outputFile.
+// The header is written when the file is created.
+type statsWriter struct {
+	genetics.BaseObserver
+	game *Game
+}
+
+// OnGenerationEnd Appends the statistics of the generation.
+func (w *statsWriter) OnGenerationEnd(stats genetics.GenerationStats) {
+	if utils.Settings.OutputFile == "" {
+		return
+	}
+
+	if err := w.write(stats); err != nil {
+		slog.Error("could not write statistics", "err", err)
+	}
+}
+
+// write Appends a row to the statistics file of the current level.
+func (w *statsWriter) write(stats genetics.GenerationStats) error {
+	file, err := os.OpenFile(w.game.levelPath(utils.Settings.OutputFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(file)
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		writer.Write([]string{"generation", "level", "best_fitness", "avg_fitness", "worst_fitness", "avg_distance", "alive", "dead", "won", "win_rate"})
+	}
+
+	writer.Write([]string{
+		strconv.Itoa(stats.Generation),
+		strconv.Itoa(w.game.level),
+		strconv.FormatFloat(stats.BestFitness, 'f', 6, 64),
+		strconv.FormatFloat(stats.AvgFitness, 'f', 6, 64),
+		strconv.FormatFloat(stats.WorstFitness, 'f', 6, 64),
+		strconv.Itoa(stats.AvgDistance),
+		strconv.Itoa(stats.Alive),
+		strconv.Itoa(stats.Dead),
+		strconv.Itoa(stats.Won),
+		strconv.FormatFloat(stats.WinRate(), 'f', 4, 64),
+	})
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// genomeSaver Saves the genome of every new best box to the file set in bestGenomeFile.
+type genomeSaver struct {
+	genetics.BaseObserver
+	game *Game
+}
+
+// OnNewBest Saves the genome of the box.
+func (s *genomeSaver) OnNewBest(generation int, box *population.Box) {
+	if utils.Settings.BestGenomeFile == "" {
+		return
+	}
+
+	path := s.game.levelPath(utils.Settings.BestGenomeFile)
+	if err := population.SaveGenomes(path, []population.DNA{box.Genes.Clone()}); err != nil {
+		slog.Error("could not save best genome", "err", err)
+	}
+}
+
+// paretoWriter Appends the Pareto front of every generation to the file set in paretoFile.
+type paretoWriter struct {
+	genetics.BaseObserver
+	game *Game
+}
+
+// OnGenerationEnd Appends the Pareto front of the generation when NSGA-II is used.
+func (w *paretoWriter) OnGenerationEnd(stats genetics.GenerationStats) {
+	if utils.DNASettings.Algorithm != utils.AlgorithmNSGA2 || utils.Settings.ParetoFile == "" {
+		return
+	}
+
+	if err := w.write(stats.Generation); err != nil {
+		slog.Error("could not write Pareto front", "err", err)
+	}
+}
+
+// write Appends the Pareto front to the file of the current level.
+func (w *paretoWriter) write(generation int) error {
+	file, err := os.OpenFile(w.game.levelPath(utils.Settings.ParetoFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if err := genetics.WriteParetoFront(file, generation, w.game.geneticAlgorithm.ParetoFront); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
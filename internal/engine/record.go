@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// recordTPS is the rate of the game loop, which the frames of a GIF are timed on.
+const recordTPS = 60
+
+// recordPalette is the palette of the GIF frames: the web-safe colors, which hold the goal,
+// the walls and the boxes, and the other colors drawn by the game.
+var recordPalette = func() color.Palette {
+	colors := append(color.Palette{}, palette.WebSafe...)
+	for _, clr := range islandColors {
+		colors = append(colors, clr)
+	}
+	for _, clr := range replayColors {
+		colors = append(colors, clr)
+	}
+	return append(colors, bestColor, avgColor, worstColor)
+}()
+
+// Recorder writes the frames of a rollout to an animated GIF, or to numbered PNG files
+// when the path ends in .png: frame.png is written as frame_00000.png, frame_00001.png, ...
+// which ffmpeg stitches with -i frame_%05d.png.
+// The window draws the frames into an offscreen image with Capture, so the recording does not
+// depend on the window; headless runs draw them with the software renderer and Add them.
+type Recorder struct {
+	path  string
+	step  int
+	count int
+
+	offscreen *ebiten.Image
+	pixels    *image.RGBA
+
+	// animation holds the GIF frames until Close. Every frame after the first only holds
+	// the rectangle that changed, drawn over the previous one.
+	animation *gif.GIF
+	previous  *image.Paletted
+	current   *image.Paletted
+	indexes   map[color.RGBA]uint8
+}
+
+// NewRecorder Creates a recorder writing to path every step-th frame of a rollout.
+func NewRecorder(path string, step int) *Recorder {
+	recorder := &Recorder{path: path, step: max(step, 1)}
+	if !strings.EqualFold(filepath.Ext(path), ".png") {
+		recorder.animation = &gif.GIF{
+			Config: image.Config{ColorModel: recordPalette, Width: utils.GameWidth, Height: utils.GameHeight},
+		}
+		recorder.indexes = map[color.RGBA]uint8{}
+	}
+
+	return recorder
+}
+
+// Due Reports whether the given frame of the rollout is recorded.
+func (r *Recorder) Due(frame int) bool {
+	return frame%r.step == 0
+}
+
+// Frames Returns the number of frames recorded.
+func (r *Recorder) Frames() int {
+	return r.count
+}
+
+// Capture Records the frame drawn by draw, e.g. Game.Draw. It must be called from the game loop,
+// as Ebiten only reads images back while it runs.
+func (r *Recorder) Capture(draw func(screen *ebiten.Image)) error {
+	if r.offscreen == nil {
+		r.offscreen = ebiten.NewImage(utils.GameWidth, utils.GameHeight)
+		r.pixels = image.NewRGBA(image.Rect(0, 0, utils.GameWidth, utils.GameHeight))
+	}
+
+	r.offscreen.Clear()
+	draw(r.offscreen)
+	r.offscreen.ReadPixels(r.pixels.Pix)
+
+	return r.Add(r.pixels)
+}
+
+// Add Records a frame of the size of the arena. PNG frames are written right away,
+// GIF frames are kept until Close.
+func (r *Recorder) Add(frame *image.RGBA) error {
+	if r.animation == nil {
+		if err := savePNG(framePath(r.path, r.count), frame); err != nil {
+			return err
+		}
+		r.count++
+		return nil
+	}
+
+	if r.current == nil {
+		r.current = image.NewPaletted(frame.Bounds(), recordPalette)
+	}
+	r.quantize(frame)
+
+	delay := max(r.step*100/recordTPS, 2)
+	bounds := r.current.Bounds()
+	if r.previous != nil {
+		bounds = changedBounds(r.previous, r.current)
+	}
+
+	if bounds.Empty() {
+		// Nothing moved: the previous frame is shown longer.
+		r.animation.Delay[len(r.animation.Delay)-1] += delay
+	} else {
+		r.animation.Image = append(r.animation.Image, cropPaletted(r.current, bounds))
+		r.animation.Delay = append(r.animation.Delay, delay)
+		r.previous, r.current = r.current, r.previous
+	}
+
+	r.count++
+	return nil
+}
+
+// Close Writes the GIF. PNG frames are already written.
+func (r *Recorder) Close() error {
+	if r.animation == nil || len(r.animation.Image) == 0 {
+		return nil
+	}
+
+	file, err := os.Create(r.path)
+	if err != nil {
+		return err
+	}
+
+	if err := gif.EncodeAll(file, r.animation); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// quantize Converts the frame to the palette into r.current. The game draws few colors,
+// so the palette index of every color is looked up once.
+func (r *Recorder) quantize(frame *image.RGBA) {
+	var last color.RGBA
+	var index uint8
+	found := false
+
+	for i := range r.current.Pix {
+		p := frame.Pix[4*i : 4*i+4 : 4*i+4]
+		clr := color.RGBA{p[0], p[1], p[2], p[3]}
+		if !found || clr != last {
+			var ok bool
+			if index, ok = r.indexes[clr]; !ok {
+				index = uint8(recordPalette.Index(clr))
+				r.indexes[clr] = index
+			}
+			last, found = clr, true
+		}
+		r.current.Pix[i] = index
+	}
+}
+
+// changedBounds Returns the smallest rectangle holding every pixel that differs between the frames.
+func changedBounds(previous, current *image.Paletted) image.Rectangle {
+	bounds := current.Bounds()
+	changed := image.Rectangle{}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		offset := current.PixOffset(bounds.Min.X, y)
+		before := previous.Pix[offset : offset+bounds.Dx()]
+		after := current.Pix[offset : offset+bounds.Dx()]
+
+		first := -1
+		for x := range after {
+			if after[x] != before[x] {
+				first = x
+				break
+			}
+		}
+		if first < 0 {
+			continue
+		}
+
+		last := len(after) - 1
+		for after[last] == before[last] {
+			last--
+		}
+
+		row := image.Rect(bounds.Min.X+first, y, bounds.Min.X+last+1, y+1)
+		changed = changed.Union(row)
+	}
+
+	return changed
+}
+
+// cropPaletted Returns a copy of the rectangle of the image, which does not share its pixels.
+func cropPaletted(img *image.Paletted, rect image.Rectangle) *image.Paletted {
+	cropped := image.NewPaletted(rect, img.Palette)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(rect.Min.X, y):img.PixOffset(rect.Max.X, y)]
+		copy(cropped.Pix[cropped.PixOffset(rect.Min.X, y):], row)
+	}
+
+	return cropped
+}
+
+// framePath Returns the path of a PNG frame: the frame number is added before the extension.
+func framePath(path string, frame int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s_%05d%s", strings.TrimSuffix(path, ext), frame, ext)
+}
+
+// savePNG Writes an image to a PNG file, creating its directory if needed.
+func savePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
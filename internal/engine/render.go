@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/pipawoz/go_genetic_algorithm/internal/render"
+)
+
+// whiteImage is the source of every shape: a white pixel tinted by the vertex colors.
+// The pixel is taken inside a larger image, so its edges are not sampled. It is created
+// on the first draw, so headless runs, which never draw, do not allocate it.
+var whiteImage, whitePixel *ebiten.Image
+
+// shapeSource Returns the white pixel the shapes are drawn from.
+func shapeSource() *ebiten.Image {
+	if whitePixel == nil {
+		whiteImage = ebiten.NewImage(3, 3)
+		whiteImage.Fill(color.White)
+		whitePixel = whiteImage.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+	}
+
+	return whitePixel
+}
+
+// ebitenSurface draws the triangles of a render.Batch on an Ebiten image.
+// The vertices are converted into a buffer kept from one draw to the next.
+type ebitenSurface struct {
+	image    *ebiten.Image
+	vertices []ebiten.Vertex
+}
+
+// Fill Fills the whole image with a color.
+func (s *ebitenSurface) Fill(clr color.Color) {
+	s.image.Fill(clr)
+}
+
+// DrawTriangles Draws the triangles from the white pixel, tinted by the vertex colors.
+func (s *ebitenSurface) DrawTriangles(vertices []render.Vertex, indices []uint16) {
+	s.vertices = s.vertices[:0]
+	for _, v := range vertices {
+		s.vertices = append(s.vertices, ebiten.Vertex{
+			DstX: v.X, DstY: v.Y, SrcX: 1, SrcY: 1,
+			ColorR: v.R, ColorG: v.G, ColorB: v.B, ColorA: v.A,
+		})
+	}
+
+	options := &ebiten.DrawTrianglesOptions{ColorScaleMode: ebiten.ColorScaleModePremultipliedAlpha}
+	s.image.DrawTriangles(s.vertices, indices, shapeSource(), options)
+}
+
+// ebitenRenderer is the render.Renderer of the window: it draws on an Ebiten image,
+// such as the screen, and needs the graphics context of the game loop.
+// The shapes are batched, so thousands of boxes cost one draw call; they are drawn by
+// Flush, which must be called before drawing on the image in any other way.
+// With perShape, every rectangle is drawn at once from an image of its own, as the
+// window used to; the draw benchmark compares both.
+type ebitenRenderer struct {
+	render.Batch
+	surface  ebitenSurface
+	perShape bool
+}
+
+// begin Starts drawing on the image. The previous image must have been flushed.
+func (e *ebitenRenderer) begin(image *ebiten.Image) *ebitenRenderer {
+	e.surface.image = image
+	e.Begin(&e.surface)
+	return e
+}
+
+// FillRect Fills the rectangle at x, y with a color.
+func (e *ebitenRenderer) FillRect(x, y, width, height float32, clr color.Color) {
+	if !e.perShape {
+		e.Batch.FillRect(x, y, width, height, clr)
+		return
+	}
+
+	img := ebiten.NewImage(int(width), int(height))
+	img.Fill(clr)
+
+	opts := &ebiten.DrawImageOptions{}
+	opts.GeoM.Translate(float64(x), float64(y))
+	e.surface.image.DrawImage(img, opts)
+}
+
+// StrokeLine Draws a line of the given width from x0, y0 to x1, y1.
+func (e *ebitenRenderer) StrokeLine(x0, y0, x1, y1, width float32, clr color.Color) {
+	if !e.perShape {
+		e.Batch.StrokeLine(x0, y0, x1, y1, width, clr)
+		return
+	}
+
+	vector.StrokeLine(e.surface.image, x0, y0, x1, y1, width, clr, false)
+}
+
+// SetPerShapeDrawing Draws every rectangle of the window from an image of its own instead of
+// batching the shapes, as the window used to. It is much slower and only meant for benchmarks.
+func (g *Game) SetPerShapeDrawing(perShape bool) {
+	g.renderer.perShape = perShape
+}
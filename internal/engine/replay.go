@@ -0,0 +1,279 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+	"log/slog"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/internal/render"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// TrajectoryFrame is the state of a box after a frame of a replay.
+type TrajectoryFrame struct {
+	Position     utils.Vector
+	Velocity     utils.Vector
+	Acceleration utils.Vector
+	IsAlive      bool
+	Won          bool
+}
+
+// RecordTrajectory simulates a single genome on a level and returns its state on every frame.
+// The simulation follows the same rules as Game.Update, so the result is deterministic.
+// The first frame is the starting state of the box.
+func RecordTrajectory(genes population.DNA, walls []utils.Obstacle, moveLimit int) []TrajectoryFrame {
+	dna := genes.Clone()
+	dna.Extend(population.GeneLength)
+	box := population.NewBox(&dna)
+
+	frames := []TrajectoryFrame{trajectoryFrame(box)}
+	for counter := 0; counter <= moveLimit && box.IsAlive && !box.Won; counter++ {
+		box.Update(counter)
+		box.CheckCollision(walls)
+		frames = append(frames, trajectoryFrame(box))
+	}
+
+	return frames
+}
+
+// trajectoryFrame Captures the current state of the box.
+func trajectoryFrame(box *population.Box) TrajectoryFrame {
+	return TrajectoryFrame{
+		Position:     box.Position,
+		Velocity:     box.Velocity,
+		Acceleration: box.Acceleration,
+		IsAlive:      box.IsAlive,
+		Won:          box.Won,
+	}
+}
+
+// replaySpeeds are the playback speeds in frames per tick.
+var replaySpeeds = []float64{0.125, 0.25, 0.5, 1, 2, 4, 8, 16}
+
+// replayColors are the trail colors of the replayed genomes.
+var replayColors = []color.RGBA{
+	{255, 80, 80, 255},
+	{80, 160, 255, 255},
+	{255, 200, 40, 255},
+	{200, 90, 255, 255},
+	{60, 230, 200, 255},
+	{255, 140, 200, 255},
+}
+
+// Timeline bar geometry, at the bottom of the window.
+const (
+	timelineX      = 10
+	timelineY      = utils.GameHeight - 20
+	timelineWidth  = utils.GameWidth - 20
+	timelineHeight = 10
+)
+
+// Replay plays back saved genomes on a level.
+// Space pauses, Left/Right step a frame, Up/Down change the speed, Home/End jump to
+// the start or the end, Tab selects the box shown in the readout and clicking or
+// dragging on the timeline scrubs.
+type Replay struct {
+	level        int
+	walls        []utils.Obstacle
+	trajectories [][]TrajectoryFrame
+	length       int
+	frame        int
+	progress     float64
+	speed        int
+	paused       bool
+	selected     int
+	recorder     *Recorder
+	renderer     ebitenRenderer
+}
+
+// NewReplay Creates a replay of the genomes on the given level.
+func NewReplay(genomes []population.DNA, level int) *Replay {
+	moveLimit, walls := utils.LevelLayout(level)
+
+	replay := &Replay{
+		level: level,
+		walls: walls,
+		speed: 3,
+	}
+
+	for _, genome := range genomes {
+		trajectory := RecordTrajectory(genome, walls, moveLimit)
+		replay.trajectories = append(replay.trajectories, trajectory)
+		if len(trajectory) > replay.length {
+			replay.length = len(trajectory)
+		}
+	}
+
+	return replay
+}
+
+// Record Makes the replay record the whole playback to path instead of following the
+// playback controls, and end once the last frame is recorded. A {} in the path is replaced by the level.
+func (r *Replay) Record(path string, step int) {
+	r.recorder = NewRecorder(levelPath(path, r.level), step)
+	r.frame = 0
+}
+
+// Update Handles the playback controls and advances the replay.
+func (r *Replay) Update() error {
+	if r.recorder != nil {
+		return r.recordFrame()
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		r.paused = !r.paused
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) && r.speed < len(replaySpeeds)-1 {
+		r.speed++
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) && r.speed > 0 {
+		r.speed--
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) && len(r.trajectories) > 0 {
+		r.selected = (r.selected + 1) % len(r.trajectories)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
+		r.paused = true
+		r.seek(r.frame + 1)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+		r.paused = true
+		r.seek(r.frame - 1)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyHome) {
+		r.seek(0)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnd) {
+		r.seek(r.length - 1)
+	}
+
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		x, y := ebiten.CursorPosition()
+		if y >= timelineY-5 && y <= timelineY+timelineHeight+5 {
+			r.seek((x - timelineX) * (r.length - 1) / timelineWidth)
+		}
+	}
+
+	if !r.paused && r.frame < r.length-1 {
+		r.progress += replaySpeeds[r.speed]
+		for r.progress >= 1 {
+			r.progress--
+			r.frame++
+		}
+		r.seek(r.frame)
+	}
+
+	return nil
+}
+
+// recordFrame Records the current frame and moves to the next recorded one.
+// After the last frame the recording is saved and the replay ends.
+func (r *Replay) recordFrame() error {
+	if err := r.recorder.Capture(r.Draw); err != nil {
+		return err
+	}
+
+	if r.frame < r.length-1 {
+		r.seek(r.frame + r.recorder.step)
+		return nil
+	}
+
+	if err := r.recorder.Close(); err != nil {
+		return err
+	}
+
+	slog.Info("recording saved", "path", r.recorder.path, "frames", r.recorder.Frames())
+	return ebiten.Termination
+}
+
+// seek Moves the replay to the given frame, clamped to the recorded frames.
+func (r *Replay) seek(frame int) {
+	if frame < 0 {
+		frame = 0
+	}
+
+	if frame > r.length-1 {
+		frame = r.length - 1
+	}
+
+	r.frame = frame
+}
+
+// Draw Draws the level, the trails up to the current frame and the readouts.
+func (r *Replay) Draw(screen *ebiten.Image) {
+	target := r.renderer.begin(screen)
+	drawScene(target, r.walls)
+
+	for i, trajectory := range r.trajectories {
+		last := min(r.frame, len(trajectory)-1)
+		drawPath(target, trajectory, last, replayColors[i%len(replayColors)])
+
+		box := population.Box{Position: trajectory[last].Position, Size: 5}
+		box.Draw(target)
+	}
+	target.Flush()
+
+	r.drawTimeline(screen)
+
+	msg := fmt.Sprintf("Replay - Level %d - Frame: %d/%d - Speed: x%g", r.level, r.frame, r.length-1, replaySpeeds[r.speed])
+	if r.paused {
+		msg += " (paused)"
+	}
+	ebitenutil.DebugPrintAt(screen, msg, 10, 10)
+
+	if len(r.trajectories) == 0 {
+		return
+	}
+
+	trajectory := r.trajectories[r.selected]
+	state := trajectory[min(r.frame, len(trajectory)-1)]
+
+	status := "alive"
+	if state.Won {
+		status = "won"
+	} else if !state.IsAlive {
+		status = "dead"
+	}
+
+	readout := fmt.Sprintf("Genome %d/%d (%s)\nPosition: (%.1f, %.1f)\nVelocity: (%.3f, %.3f)\nAcceleration: (%.3f, %.3f)",
+		r.selected+1, len(r.trajectories), status,
+		state.Position.X, state.Position.Y,
+		state.Velocity.X, state.Velocity.Y,
+		state.Acceleration.X, state.Acceleration.Y)
+	ebitenutil.DebugPrintAt(screen, readout, 10, 30)
+}
+
+// drawPath Draws the path of a trajectory up to the given frame.
+func drawPath(r render.Renderer, trajectory []TrajectoryFrame, last int, clr color.Color) {
+	for j := 1; j <= last; j++ {
+		from, to := trajectory[j-1].Position, trajectory[j].Position
+		r.StrokeLine(from.X, from.Y, to.X, to.Y, 1, clr)
+	}
+}
+
+// drawTimeline Draws the scrubbing bar with the current frame.
+func (r *Replay) drawTimeline(screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, timelineX, timelineY, timelineWidth, timelineHeight, color.RGBA{60, 60, 60, 255}, false)
+
+	if r.length > 1 {
+		played := float32(timelineWidth) * float32(r.frame) / float32(r.length-1)
+		vector.DrawFilledRect(screen, timelineX, timelineY, played, timelineHeight, color.RGBA{160, 160, 160, 255}, false)
+	}
+}
+
+// Layout Layout the replay.
+func (r *Replay) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return utils.GameWidth, utils.GameHeight
+}
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"log/slog"
+	"sort"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/genetics"
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/internal/render"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// snapshotWriter Saves a picture of the paths of the best boxes every snapshotInterval generations
+// to snapshotFile, with the generation number added before the extension.
+// The pictures are drawn with the software renderer, so headless runs need no window.
+type snapshotWriter struct {
+	genetics.BaseObserver
+	game *Game
+}
+
+// OnEvaluated Saves the snapshot of the generation when one is due.
+func (w *snapshotWriter) OnEvaluated(generation int, boxes []population.Box) {
+	interval := utils.Settings.SnapshotInterval
+	if utils.Settings.SnapshotFile == "" || interval < 1 || generation%interval != 0 {
+		return
+	}
+
+	path := framePath(w.game.levelPath(utils.Settings.SnapshotFile), generation)
+	if err := savePNG(path, w.game.snapshot(boxes).RGBA); err != nil {
+		slog.Error("could not save snapshot", "err", err)
+	}
+}
+
+// snapshot Draws the level and the paths of the snapshotBest boxes with the highest fitness,
+// the best one on top. The paths are simulated again from the genomes, which is deterministic.
+func (g *Game) snapshot(boxes []population.Box) *render.Image {
+	order := make([]int, len(boxes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return boxes[order[a]].Fitness > boxes[order[b]].Fitness })
+
+	img := render.NewImage(utils.GameWidth, utils.GameHeight)
+	drawScene(img, g.walls)
+
+	for rank := min(utils.Settings.SnapshotBest, len(boxes)) - 1; rank >= 0; rank-- {
+		box := &boxes[order[rank]]
+		clr := replayColors[rank%len(replayColors)]
+
+		trajectory := RecordTrajectory(box.Genes, g.walls, g.moveLimit)
+		drawPath(img, trajectory, len(trajectory)-1, clr)
+
+		end := population.Box{Position: trajectory[len(trajectory)-1].Position, Size: box.Size}
+		end.DrawColor(img, clr)
+	}
+
+	return img
+}
+
+// recordRollout Records the generation that was just simulated without a window: the rollout of
+// every box is simulated again from its genome and drawn with the software renderer.
+// The frames hold the level, the boxes and, with printTrace, their trails, but not the text of the window.
+func (g *Game) recordRollout() error {
+	path := g.levelPath(utils.Settings.RecordFile)
+	recorder := NewRecorder(path, utils.Settings.RecordFrameStep)
+
+	boxes := g.geneticAlgorithm.Population
+	trajectories := make([][]TrajectoryFrame, len(boxes))
+	frames := 0
+	for i := range boxes {
+		trajectories[i] = RecordTrajectory(boxes[i].Genes, g.walls, g.moveLimit)
+		frames = max(frames, len(trajectories[i])-1)
+	}
+
+	scene := render.NewImage(utils.GameWidth, utils.GameHeight)
+	drawScene(scene, g.walls)
+	trails := render.NewImage(utils.GameWidth, utils.GameHeight)
+	frame := image.NewRGBA(scene.RGBA.Bounds())
+	islands := len(g.geneticAlgorithm.Islands)
+
+	// Frame counter holds the boxes after their update of that frame, as Game.Update records them.
+	for counter := 0; counter < frames; counter++ {
+		if !g.showTrails {
+			trails.Fill(color.Transparent)
+		}
+
+		for i, trajectory := range trajectories {
+			state := trajectory[min(counter+1, len(trajectory)-1)]
+			if state.IsAlive {
+				box := population.Box{Position: state.Position, Size: boxes[i].Size, Island: boxes[i].Island}
+				drawBox(trails, &box, islands)
+			}
+		}
+
+		if !recorder.Due(counter) && counter != frames-1 {
+			continue
+		}
+
+		copy(frame.Pix, scene.RGBA.Pix)
+		draw.Draw(frame, frame.Bounds(), trails.RGBA, image.Point{}, draw.Over)
+		if err := recorder.Add(frame); err != nil {
+			return err
+		}
+	}
+
+	if err := recorder.Close(); err != nil {
+		return err
+	}
+
+	slog.Info("recording saved", "path", path, "generation", g.currentGeneration, "frames", recorder.Frames())
+	return nil
+}
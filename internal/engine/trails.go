@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/pipawoz/go_genetic_algorithm/internal/genetics"
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// trailModes are the trail modes in the order the T key cycles them.
+var trailModes = []string{utils.TrailSolid, utils.TrailFade, utils.TrailRank, utils.TrailLineage, utils.TrailBest}
+
+// ghostColor is the color of the path of the best box of the previous generation.
+var ghostColor = color.RGBA{110, 110, 110, 110}
+
+// trailFrame identifies a frame of the run, so the trails are painted once per simulated frame
+// however many times the game is drawn.
+type trailFrame struct {
+	generation, counter int
+}
+
+// ghostTracker Keeps the path of the best box of every generation, shown over the next one
+// when the ghost is on. The path is simulated again from the genome, which is deterministic.
+type ghostTracker struct {
+	genetics.BaseObserver
+	game *Game
+}
+
+// OnEvaluated Records the path of the best box of the generation.
+func (t *ghostTracker) OnEvaluated(generation int, boxes []population.Box) {
+	if t.game.headless || len(boxes) == 0 {
+		return
+	}
+
+	best := 0
+	for i := range boxes {
+		if boxes[i].Fitness > boxes[best].Fitness {
+			best = i
+		}
+	}
+
+	t.game.ghost = RecordTrajectory(boxes[best].Genes, t.game.walls, t.game.moveLimit)
+}
+
+// handleTrailKeys Handles the keys of the trails: T cycles the trail modes and G shows the ghost.
+func (g *Game) handleTrailKeys() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyT) {
+		g.cycleTrailMode(1)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyG) {
+		g.showGhost = !g.showGhost
+	}
+}
+
+// cycleTrailMode Switches to the next trail mode, or the previous one when direction is negative.
+// The trails of the previous mode are cleared.
+func (g *Game) cycleTrailMode(direction int) {
+	current := 0
+	for i, mode := range trailModes {
+		if mode == g.trailMode {
+			current = i
+		}
+	}
+
+	g.trailMode = trailModes[(current+direction+len(trailModes))%len(trailModes)]
+	if g.trailImage != nil {
+		g.trailImage.Clear()
+	}
+}
+
+// drawBoxes Draws the living boxes in the colors of the trail mode. The boxes leaving a trail are
+// painted on the trail image once per simulated frame; in the best mode the others are drawn on
+// the screen, over the trails.
+func (g *Game) drawBoxes(screen *ebiten.Image) {
+	if g.trailImage == nil {
+		g.trailImage = ebiten.NewImage(utils.GameWidth, utils.GameHeight)
+	}
+
+	boxes := g.geneticAlgorithm.Population
+	frame := trailFrame{g.currentGeneration, g.counter}
+	paint := frame != g.paintedFrame
+	g.paintedFrame = frame
+
+	if paint {
+		switch {
+		case !g.showTrails:
+			g.trailImage.Clear()
+		case g.trailMode == utils.TrailFade:
+			g.fadeTrails()
+		}
+	}
+
+	var ranks []int
+	if g.trailMode == utils.TrailRank || g.trailMode == utils.TrailBest {
+		ranks = progressRanks(boxes)
+	}
+
+	trails := g.renderer.begin(g.trailImage)
+	var untrailed []int
+	for i := range boxes {
+		if !boxes[i].IsAlive {
+			continue
+		}
+
+		if g.trailMode == utils.TrailBest && ranks[i] >= utils.Settings.TrailBest {
+			untrailed = append(untrailed, i)
+		} else if paint {
+			boxes[i].DrawColor(trails, g.boxColor(&boxes[i], i, ranks))
+		}
+	}
+
+	trails.Flush()
+	screen.DrawImage(g.trailImage, nil)
+
+	target := g.renderer.begin(screen)
+	for _, i := range untrailed {
+		boxes[i].DrawColor(target, g.boxColor(&boxes[i], i, ranks))
+	}
+
+	if g.showGhost && len(g.ghost) > 0 {
+		drawPath(target, g.ghost, len(g.ghost)-1, ghostColor)
+	}
+	target.Flush()
+}
+
+// fadeTrails Multiplies the alpha of the trails by trailFade, so the older parts fade out.
+func (g *Game) fadeTrails() {
+	if g.fadeImage == nil {
+		g.fadeImage = ebiten.NewImage(utils.GameWidth, utils.GameHeight)
+	}
+
+	g.fadeImage.Clear()
+	opts := &ebiten.DrawImageOptions{}
+	opts.ColorScale.ScaleAlpha(float32(utils.Settings.TrailFade))
+	g.fadeImage.DrawImage(g.trailImage, opts)
+	g.trailImage, g.fadeImage = g.fadeImage, g.trailImage
+}
+
+// boxColor Returns the color of the i-th box in the trail mode. ranks holds the rank of every
+// box in the rank and best modes.
+func (g *Game) boxColor(box *population.Box, i int, ranks []int) color.Color {
+	switch g.trailMode {
+	case utils.TrailRank:
+		// From green for the box closest to the goal to red for the farthest.
+		return hueColor(float64(len(ranks)-1-ranks[i]) / float64(max(len(ranks)-1, 1)) / 3)
+	case utils.TrailLineage:
+		return hueColor(goldenHue(lineageKey(box)))
+	}
+
+	if len(g.geneticAlgorithm.Islands) > 1 {
+		return islandColors[box.Island%len(islandColors)]
+	}
+
+	return color.RGBA{255, 0, 0, 255}
+}
+
+// lineageKey Returns the number boxes of the same family share: the species they were bred in,
+// or their first parent without speciation.
+func lineageKey(box *population.Box) uint64 {
+	switch {
+	case box.Species > 0:
+		return uint64(box.Island)<<32 | uint64(box.Species)
+	case len(box.Lineage.Parents) > 0:
+		return box.Lineage.Parents[0]
+	}
+
+	return box.Lineage.ID
+}
+
+// progressRanks Returns the rank of every box by its distance to the goal, 0 being the closest.
+func progressRanks(boxes []population.Box) []int {
+	order := make([]int, len(boxes))
+	distances := make([]float64, len(boxes))
+	for i := range boxes {
+		order[i] = i
+		dx := float64(boxes[i].Position.X - utils.GoalX)
+		dy := float64(boxes[i].Position.Y - utils.GoalY)
+		distances[i] = math.Hypot(dx, dy)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return distances[order[a]] < distances[order[b]] })
+
+	ranks := make([]int, len(boxes))
+	for rank, i := range order {
+		ranks[i] = rank
+	}
+
+	return ranks
+}
+
+// goldenHue Spreads keys over the hues, so consecutive keys get distant colors.
+func goldenHue(key uint64) float64 {
+	_, hue := math.Modf(float64(key) * 0.6180339887498949)
+	return hue
+}
+
+// hueColor Returns the bright color of the hue, from 0 to 1: red, then green at 1/3 and blue at 2/3.
+func hueColor(hue float64) color.RGBA {
+	channel := func(offset float64) uint8 {
+		k := math.Mod(offset+hue*6, 6)
+		return uint8(255 * (1 - 0.8*math.Max(0, math.Min(math.Min(k, 4-k), 1))))
+	}
+
+	return color.RGBA{channel(5), channel(3), channel(1), 255}
+}
+
+// trailLabel Returns the trail mode shown in the window.
+func (g *Game) trailLabel() string {
+	label := fmt.Sprintf("Trails: %s", g.trailMode)
+	if g.showGhost {
+		label += " + ghost"
+	}
+
+	return label
+}
@@ -0,0 +1,253 @@
+package engine
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image/color"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// ParamChange is an entry of the parameter timeline of a run.
+type ParamChange struct {
+	Time       time.Time
+	Generation int
+	Frame      int
+	Name       string
+	Old        string
+	New        string
+}
+
+// tunable is a parameter that can be changed from the tuning panel.
+type tunable struct {
+	name   string
+	value  func(g *Game) string
+	adjust func(g *Game, direction int)
+}
+
+// tunables are the parameters shown in the tuning panel, in order.
+var tunables = []tunable{
+	{
+		name:  "Mutation rate",
+		value: func(g *Game) string { return strconv.FormatFloat(utils.DNASettings.MutationRate, 'f', 3, 64) },
+		adjust: func(g *Game, direction int) {
+			utils.DNASettings.MutationRate = clampRate(utils.DNASettings.MutationRate + float64(direction)*0.005)
+		},
+	},
+	{
+		name:  "Crossover rate",
+		value: func(g *Game) string { return strconv.FormatFloat(utils.DNASettings.CrossoverRate, 'f', 2, 64) },
+		adjust: func(g *Game, direction int) {
+			utils.DNASettings.CrossoverRate = clampRate(utils.DNASettings.CrossoverRate + float64(direction)*0.05)
+		},
+	},
+	{
+		name:  "Speed (TPS)",
+		value: func(g *Game) string { return strconv.Itoa(ebiten.TPS()) },
+		adjust: func(g *Game, direction int) {
+			tps := ebiten.TPS()
+			if direction > 0 && tps < 960 {
+				ebiten.SetTPS(tps * 2)
+			} else if direction < 0 && tps > 15 {
+				ebiten.SetTPS(tps / 2)
+			}
+		},
+	},
+	{
+		name:  "Trails",
+		value: func(g *Game) string { return strconv.FormatBool(g.showTrails) },
+		adjust: func(g *Game, direction int) {
+			g.showTrails = !g.showTrails
+		},
+	},
+	{
+		name:  "Trail mode",
+		value: func(g *Game) string { return g.trailMode },
+		adjust: func(g *Game, direction int) {
+			g.cycleTrailMode(direction)
+		},
+	},
+	{
+		name:  "Level",
+		value: func(g *Game) string { return strconv.Itoa(g.level) },
+		adjust: func(g *Game, direction int) {
+			levels := utils.AvailableLevels()
+			for i, level := range levels {
+				if level == g.level {
+					g.restartLevel(levels[(i+direction+len(levels))%len(levels)])
+					return
+				}
+			}
+			g.restartLevel(levels[0])
+		},
+	},
+}
+
+// clampRate keeps a rate between 0 and 1.
+func clampRate(rate float64) float64 {
+	return min(max(rate, 0), 1)
+}
+
+// Tuning panel geometry, at the top right of the window.
+const (
+	panelX         = utils.GameWidth - 250
+	panelY         = 10
+	panelWidth     = 240
+	panelRowHeight = 18
+)
+
+// handleTuningInput Handles the keyboard and mouse controls of the tuning panel.
+// Tab shows the panel, Up/Down select a parameter and Left/Right change it.
+// The < and > buttons of each row can also be clicked.
+func (g *Game) handleTuningInput() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		g.showPanel = !g.showPanel
+	}
+
+	if !g.showPanel {
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
+		g.panelIndex = (g.panelIndex + len(tunables) - 1) % len(tunables)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
+		g.panelIndex = (g.panelIndex + 1) % len(tunables)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+		g.adjustParam(g.panelIndex, -1)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
+		g.adjustParam(g.panelIndex, 1)
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		x, y := ebiten.CursorPosition()
+		row := (y - panelY - panelRowHeight) / panelRowHeight
+		if y < panelY+panelRowHeight || row >= len(tunables) {
+			return
+		}
+
+		switch {
+		case x >= panelX+170 && x < panelX+190:
+			g.panelIndex = row
+			g.adjustParam(row, -1)
+		case x >= panelX+225 && x < panelX+245:
+			g.panelIndex = row
+			g.adjustParam(row, 1)
+		}
+	}
+}
+
+// adjustParam Changes a parameter and records the change in the timeline.
+func (g *Game) adjustParam(index int, direction int) {
+	param := tunables[index]
+
+	old := param.value(g)
+	param.adjust(g, direction)
+	value := param.value(g)
+
+	if old == value {
+		return
+	}
+
+	change := ParamChange{
+		Time:       time.Now(),
+		Generation: g.currentGeneration,
+		Frame:      g.counter,
+		Name:       param.name,
+		Old:        old,
+		New:        value,
+	}
+	g.paramLog = append(g.paramLog, change)
+
+	slog.Info("parameter changed", "parameter", change.Name, "old", change.Old, "new", change.New,
+		"generation", change.Generation, "frame", change.Frame)
+
+	if err := appendParamLog(change); err != nil {
+		slog.Error("could not write parameter log", "err", err)
+	}
+}
+
+// appendParamLog Appends a change to the CSV file set in paramLogFile, if any.
+// The header is written when the file is created.
+func appendParamLog(change ParamChange) error {
+	if utils.Settings.ParamLogFile == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(utils.Settings.ParamLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(file)
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		writer.Write([]string{"time", "generation", "frame", "parameter", "old", "new"})
+	}
+
+	writer.Write([]string{
+		change.Time.Format(time.RFC3339Nano),
+		strconv.Itoa(change.Generation),
+		strconv.Itoa(change.Frame),
+		change.Name,
+		change.Old,
+		change.New,
+	})
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// ParamTimeline Returns the parameter changes made during the run, oldest first.
+func (g *Game) ParamTimeline() []ParamChange {
+	return g.paramLog
+}
+
+// restartLevel Switches to another level and restarts the current generation on it.
+func (g *Game) restartLevel(level int) {
+	g.SelectLevel(level)
+
+	for i := range g.geneticAlgorithm.Population {
+		g.geneticAlgorithm.Population[i].Reset()
+	}
+
+	g.counter = 0
+	if g.trailImage != nil {
+		g.trailImage.Clear()
+	}
+}
+
+// drawTuningPanel Draws the tuning panel with the current value of every parameter.
+func (g *Game) drawTuningPanel(screen *ebiten.Image) {
+	height := float32(panelRowHeight * (len(tunables) + 1))
+	vector.DrawFilledRect(screen, panelX, panelY, panelWidth, height, color.RGBA{20, 20, 20, 200}, false)
+
+	ebitenutil.DebugPrintAt(screen, "Tuning (Tab to hide)", panelX+5, panelY)
+
+	for i, param := range tunables {
+		y := panelY + panelRowHeight*(i+1)
+		if i == g.panelIndex {
+			vector.DrawFilledRect(screen, panelX, float32(y), panelWidth, panelRowHeight, color.RGBA{70, 70, 120, 200}, false)
+		}
+
+		ebitenutil.DebugPrintAt(screen, param.name, panelX+5, y)
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("<  %-5s  >", param.value(g)), panelX+175, y)
+	}
+}
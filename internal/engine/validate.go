@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// ValidateConfig checks the configuration files, the loaded settings and the levels.
+// All the problems are reported at once in a utils.ValidationErrors.
+func ValidateConfig() error {
+	gameFile := filepath.Join(utils.ConfigDir, utils.GameSettingsFile)
+	geneticFile := filepath.Join(utils.ConfigDir, utils.GeneticSettingsFile)
+
+	var errs utils.ValidationErrors
+	errs = append(errs, utils.ValidateFile(gameFile, &utils.GameSettings{})...)
+	errs = append(errs, utils.ValidateFile(geneticFile, &utils.GeneticSettings{})...)
+	errs = append(errs, utils.ValidateGameSettings(gameFile, utils.Settings)...)
+	errs = append(errs, utils.ValidateGeneticSettings(geneticFile, utils.DNASettings)...)
+
+	if utils.Settings.LevelsFile != "" {
+		errs = append(errs, utils.ValidateLevelsFile(utils.Settings.LevelsFile)...)
+	}
+
+	for level := 1; level <= utils.BuiltinLevels; level++ {
+		if _, ok := utils.CustomLevels[level]; ok {
+			continue
+		}
+
+		moveLimit, walls := utils.LevelLayout(level)
+		errs = append(errs, utils.ValidateLevel("levels.go", fmt.Sprintf("level %d", level), moveLimit, walls)...)
+	}
+
+	if !LevelExists(utils.Settings.CurrentLevel) {
+		errs = append(errs, utils.ValidationError{
+			File:    gameFile,
+			Field:   "currentLevel",
+			Message: fmt.Sprintf("level %d does not exist, use 1 to %d or a level of the levels file", utils.Settings.CurrentLevel, utils.BuiltinLevels),
+		})
+	}
+
+	if utils.Settings.RecordGeneration > utils.DNASettings.MaxGenerations {
+		errs = append(errs, utils.ValidationError{
+			File:    gameFile,
+			Field:   "recordGeneration",
+			Message: fmt.Sprintf("must be at most maxGenerations (%d), got %d", utils.DNASettings.MaxGenerations, utils.Settings.RecordGeneration),
+		})
+	}
+
+	for i, stage := range utils.Settings.Curriculum {
+		if stage.Level >= 1 && !LevelExists(stage.Level) {
+			errs = append(errs, utils.ValidationError{
+				File:    gameFile,
+				Field:   fmt.Sprintf("curriculum[%d].level", i),
+				Message: fmt.Sprintf("level %d does not exist, use 1 to %d or a level of the levels file", stage.Level, utils.BuiltinLevels),
+			})
+		}
+	}
+
+	return errs.Err()
+}
+
+// LevelExists Reports whether the level is built in or loaded from the levels file.
+func LevelExists(level int) bool {
+	_, ok := utils.CustomLevels[level]
+	return ok || (level >= 1 && level <= utils.BuiltinLevels)
+}
@@ -0,0 +1,85 @@
+package genetics
+
+import (
+	"math/rand"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/pkg/ga"
+)
+
+// boxGenome adapts a box to the ga.Genome interface, so the maze is bred by the ga package.
+// The operators keep the lineage of the boxes.
+type boxGenome struct {
+	box population.Box
+}
+
+// Crossover Crosses the genes of both boxes at a random point.
+func (g boxGenome) Crossover(partner boxGenome, rng *rand.Rand) (boxGenome, boxGenome) {
+	length := min(len(g.box.Genes.Chain), len(partner.box.Genes.Chain))
+	a, b := g.box.CrossoverAt(partner.box, rng.Intn(length-1)+1)
+	return boxGenome{a}, boxGenome{b}
+}
+
+// Mutate Multiplies the X or Y value of a random gene by 1.01 with the given rate.
+func (g boxGenome) Mutate(rate float64, rng *rand.Rand) boxGenome {
+	if rng.Float64() >= rate {
+		return g
+	}
+
+	g.box.Genes = g.box.Genes.Clone()
+	g.box.Lineage.Mutations = append([]int(nil), g.box.Lineage.Mutations...)
+	g.box.MutateGene(rng.Intn(len(g.box.Genes.Chain)-1), rng.Intn(10) > 5)
+	return g
+}
+
+// Clone Returns a copy of the box as a new individual whose only parent is the box.
+func (g boxGenome) Clone() boxGenome {
+	return boxGenome{g.box.Offspring()}
+}
+
+// globalSource is a rand.Source drawing from the global source of math/rand,
+// so the maze keeps using a single sequence that the seed setting controls.
+type globalSource struct{}
+
+// Int63 Returns a number from the global source.
+func (globalSource) Int63() int64 { return rand.Int63() }
+
+// Seed Does nothing; the global source is seeded by the program.
+func (globalSource) Seed(int64) {}
+
+// globalRand draws from the global source of math/rand.
+var globalRand = rand.New(globalSource{})
+
+// gaBreed Breeds count offspring of the boxes with the roulette selection of the ga package.
+// The offspring are mutated with the rates of the island and belong to it.
+func (island *Island) gaBreed(boxes []population.Box, count int) []population.Box {
+	fitness := make([]float64, len(boxes))
+	for i := range boxes {
+		fitness[i] = boxes[i].Fitness
+	}
+
+	return island.gaBreedWith(boxes, fitness, ga.Roulette(), count)
+}
+
+// gaBreedWith Breeds count offspring of the boxes with the ga package, picking the parents
+// with selection over the given scores instead of the fitness of the boxes.
+func (island *Island) gaBreedWith(boxes []population.Box, scores []float64, selection ga.Selection, count int) []population.Box {
+	parents := make(ga.Population[boxGenome], len(boxes))
+	for i := range boxes {
+		parents[i] = ga.Individual[boxGenome]{Genome: boxGenome{boxes[i]}, Fitness: scores[i]}
+	}
+
+	config := ga.Config{
+		MutationRate:  island.mutationRate(),
+		CrossoverRate: island.crossoverRate(),
+		Selection:     selection,
+		Rand:          globalRand,
+	}
+
+	offspring := make([]population.Box, 0, count)
+	for _, genome := range ga.Breed(config, parents, count) {
+		offspring = append(offspring, genome.box)
+	}
+
+	return offspring
+}
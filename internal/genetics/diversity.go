@@ -0,0 +1,195 @@
+package genetics
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// Limits that keep the diversity metrics affordable for large populations.
+const (
+	// diversityPairs is the number of genome pairs sampled when there are more pairs than this.
+	diversityPairs = 500
+	// entropyGenes is the number of gene positions sampled for the entropy.
+	entropyGenes = 200
+	// entropyBins is the number of angle bins of the entropy histograms.
+	entropyBins = 16
+	// clusterRadius is the distance in pixels under which final positions are in the same cluster.
+	clusterRadius = 40
+	// diversitySeed seeds the sampling of the pairs, so the metrics are reproducible.
+	diversitySeed = 1
+)
+
+// Diversity holds the diversity metrics of an evaluated generation.
+type Diversity struct {
+	// GenomeDistance is the mean distance between the genes of two genomes, averaged over pairs.
+	GenomeDistance float64 `json:"genomeDistance"`
+	// PositionalSpread is the root mean square distance of the final positions to their centroid.
+	PositionalSpread float64 `json:"positionalSpread"`
+	// Clusters is the number of groups of final positions closer than clusterRadius.
+	Clusters int `json:"clusters"`
+	// GeneEntropy is the entropy of the gene angles at each position, normalized to [0, 1] and averaged.
+	GeneEntropy float64 `json:"geneEntropy"`
+}
+
+// computeDiversity Computes the diversity metrics of the population.
+// The pairs are sampled from a source of their own, so computing the metrics does not
+// change the random numbers of the evolution.
+func computeDiversity(boxes []population.Box) Diversity {
+	rng := rand.New(rand.NewSource(diversitySeed))
+
+	return Diversity{
+		GenomeDistance:   genomeDistance(boxes, rng),
+		PositionalSpread: positionalSpread(boxes),
+		Clusters:         behaviorClusters(boxes),
+		GeneEntropy:      geneEntropy(boxes),
+	}
+}
+
+// genomeDistance Returns the mean pairwise genome distance.
+// Every pair is compared in small populations and diversityPairs random pairs drawn from rng otherwise.
+func genomeDistance(boxes []population.Box, rng *rand.Rand) float64 {
+	n := len(boxes)
+	if n < 2 {
+		return 0
+	}
+
+	total, pairs := 0.0, 0
+	if n*(n-1)/2 <= diversityPairs {
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				total += chainDistance(boxes[i].Genes.Chain, boxes[j].Genes.Chain)
+				pairs++
+			}
+		}
+	} else {
+		for pairs < diversityPairs {
+			i, j := rng.Intn(n), rng.Intn(n)
+			if i == j {
+				continue
+			}
+			total += chainDistance(boxes[i].Genes.Chain, boxes[j].Genes.Chain)
+			pairs++
+		}
+	}
+
+	return total / float64(pairs)
+}
+
+// chainDistance Returns the mean Euclidean distance between the genes of two chains.
+func chainDistance(a, b []utils.Vector) float64 {
+	length := min(len(a), len(b))
+	if length == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for i := 0; i < length; i++ {
+		dx, dy := float64(a[i].X-b[i].X), float64(a[i].Y-b[i].Y)
+		total += math.Sqrt(dx*dx + dy*dy)
+	}
+
+	return total / float64(length)
+}
+
+// positionalSpread Returns the root mean square distance of the final positions to their centroid.
+func positionalSpread(boxes []population.Box) float64 {
+	if len(boxes) == 0 {
+		return 0
+	}
+
+	var cx, cy float64
+	for i := range boxes {
+		cx += float64(boxes[i].Position.X)
+		cy += float64(boxes[i].Position.Y)
+	}
+	cx /= float64(len(boxes))
+	cy /= float64(len(boxes))
+
+	total := 0.0
+	for i := range boxes {
+		dx, dy := float64(boxes[i].Position.X)-cx, float64(boxes[i].Position.Y)-cy
+		total += dx*dx + dy*dy
+	}
+
+	return math.Sqrt(total / float64(len(boxes)))
+}
+
+// behaviorClusters Counts the groups of final positions with leader clustering:
+// a box joins the first leader closer than clusterRadius or becomes a new leader.
+func behaviorClusters(boxes []population.Box) int {
+	var leaders []utils.Vector
+
+	for i := range boxes {
+		position := boxes[i].Position
+		joined := false
+		for _, leader := range leaders {
+			dx, dy := position.X-leader.X, position.Y-leader.Y
+			if dx*dx+dy*dy < clusterRadius*clusterRadius {
+				joined = true
+				break
+			}
+		}
+
+		if !joined {
+			leaders = append(leaders, position)
+		}
+	}
+
+	return len(leaders)
+}
+
+// geneEntropy Returns the Shannon entropy of the gene angles across the population,
+// normalized to [0, 1] and averaged over up to entropyGenes evenly spaced gene positions.
+// No-op genes, such as the ones of the boxes that won, are ignored.
+func geneEntropy(boxes []population.Box) float64 {
+	if len(boxes) == 0 {
+		return 0
+	}
+
+	length := len(boxes[0].Genes.Chain)
+	for i := range boxes {
+		length = min(length, len(boxes[i].Genes.Chain))
+	}
+
+	step := max(length/entropyGenes, 1)
+	total, positions := 0.0, 0
+
+	for gene := 0; gene < length; gene += step {
+		var bins [entropyBins]int
+		count := 0
+		for i := range boxes {
+			v := boxes[i].Genes.Chain[gene]
+			if v.X == 0 && v.Y == 0 {
+				continue
+			}
+
+			angle := math.Atan2(float64(v.Y), float64(v.X)) + math.Pi
+			bins[min(int(angle/(2*math.Pi)*entropyBins), entropyBins-1)]++
+			count++
+		}
+
+		if count == 0 {
+			continue
+		}
+
+		entropy := 0.0
+		for _, n := range bins {
+			if n > 0 {
+				p := float64(n) / float64(count)
+				entropy -= p * math.Log(p)
+			}
+		}
+
+		total += entropy / math.Log(entropyBins)
+		positions++
+	}
+
+	if positions == 0 {
+		return 0
+	}
+
+	return total / float64(positions)
+}
@@ -0,0 +1,285 @@
+package genetics
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+)
+
+// GenealogyRecord is an evaluated individual of the genealogy.
+type GenealogyRecord struct {
+	ID             uint64   `json:"id"`
+	Parents        []uint64 `json:"parents,omitempty"`
+	Generation     int      `json:"generation"`
+	CrossoverPoint int      `json:"crossoverPoint"`
+	Mutations      []int    `json:"mutations,omitempty"`
+	Fitness        float64  `json:"fitness"`
+	Won            bool     `json:"won"`
+	Frames         int      `json:"frames"`
+}
+
+// Genealogy stores every evaluated individual so the ancestry of any of them
+// can be traced after the run. It is safe for concurrent use.
+type Genealogy struct {
+	mu       sync.Mutex
+	records  map[uint64]*GenealogyRecord
+	children map[uint64][]uint64
+	order    []uint64
+}
+
+// NewGenealogy Creates an empty genealogy.
+func NewGenealogy() *Genealogy {
+	return &Genealogy{
+		records:  map[uint64]*GenealogyRecord{},
+		children: map[uint64][]uint64{},
+	}
+}
+
+// Record Adds an evaluated individual to the genealogy.
+func (g *Genealogy) Record(box *population.Box) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	lineage := box.Lineage
+	if _, ok := g.records[lineage.ID]; !ok {
+		g.order = append(g.order, lineage.ID)
+		for _, parent := range lineage.Parents {
+			g.children[parent] = append(g.children[parent], lineage.ID)
+		}
+	}
+
+	g.records[lineage.ID] = &GenealogyRecord{
+		ID:             lineage.ID,
+		Parents:        lineage.Parents,
+		Generation:     lineage.Generation,
+		CrossoverPoint: lineage.CrossoverPoint,
+		Mutations:      lineage.Mutations,
+		Fitness:        box.Fitness,
+		Won:            box.Won,
+		Frames:         box.Frames,
+	}
+}
+
+// Len Returns the number of recorded individuals.
+func (g *Genealogy) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return len(g.order)
+}
+
+// Get Returns the record of an individual.
+func (g *Genealogy) Get(id uint64) (GenealogyRecord, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	record, ok := g.records[id]
+	if !ok {
+		return GenealogyRecord{}, false
+	}
+
+	return *record, true
+}
+
+// Children Returns the recorded offspring of an individual.
+func (g *Genealogy) Children(id uint64) []GenealogyRecord {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var children []GenealogyRecord
+	for _, child := range g.children[id] {
+		if record, ok := g.records[child]; ok {
+			children = append(children, *record)
+		}
+	}
+
+	return children
+}
+
+// Ancestors Returns the recorded ancestors of an individual, closest generations first.
+func (g *Genealogy) Ancestors(id uint64) []GenealogyRecord {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var ancestors []GenealogyRecord
+	for _, ancestor := range g.ancestorIDs(id) {
+		if ancestor != id {
+			ancestors = append(ancestors, *g.records[ancestor])
+		}
+	}
+
+	return ancestors
+}
+
+// ancestorIDs Returns the individual and its recorded ancestors in breadth-first order.
+func (g *Genealogy) ancestorIDs(id uint64) []uint64 {
+	if _, ok := g.records[id]; !ok {
+		return nil
+	}
+
+	seen := map[uint64]bool{id: true}
+	queue := []uint64{id}
+	for i := 0; i < len(queue); i++ {
+		for _, parent := range g.records[queue[i]].Parents {
+			if _, ok := g.records[parent]; ok && !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+
+	return queue
+}
+
+// Winners Returns the individuals that reached the goal, in the order they were recorded.
+func (g *Genealogy) Winners() []GenealogyRecord {
+	return g.filter(func(record *GenealogyRecord) bool { return record.Won })
+}
+
+// Best Returns the individual with the highest fitness.
+func (g *Genealogy) Best() (GenealogyRecord, bool) {
+	records := g.filter(func(*GenealogyRecord) bool { return true })
+	if len(records) == 0 {
+		return GenealogyRecord{}, false
+	}
+
+	best := records[0]
+	for _, record := range records[1:] {
+		if record.Fitness > best.Fitness {
+			best = record
+		}
+	}
+
+	return best, true
+}
+
+// filter Returns the records that satisfy keep, in the order they were recorded.
+func (g *Genealogy) filter(keep func(*GenealogyRecord) bool) []GenealogyRecord {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var records []GenealogyRecord
+	for _, id := range g.order {
+		if keep(g.records[id]) {
+			records = append(records, *g.records[id])
+		}
+	}
+
+	return records
+}
+
+// selection Returns the IDs to export: the roots and their ancestors, or everything without roots.
+func (g *Genealogy) selection(roots []uint64) []uint64 {
+	if len(roots) == 0 {
+		return g.order
+	}
+
+	seen := map[uint64]bool{}
+	var ids []uint64
+	for _, root := range roots {
+		for _, id := range g.ancestorIDs(root) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// WriteJSON Writes the records as a JSON array.
+// With roots, only they and their ancestors are written.
+func (g *Genealogy) WriteJSON(w io.Writer, roots ...uint64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	records := []*GenealogyRecord{}
+	for _, id := range g.selection(roots) {
+		records = append(records, g.records[id])
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// WriteDOT Writes the genealogy as a Graphviz DOT graph, with an edge from every parent to its offspring.
+// Winners are filled in green. With roots, only they and their ancestors are written.
+func (g *Genealogy) WriteDOT(w io.Writer, roots ...uint64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ids := g.selection(roots)
+	included := map[uint64]bool{}
+	for _, id := range ids {
+		included[id] = true
+	}
+
+	var out strings.Builder
+	out.WriteString("digraph genealogy {\n\trankdir=LR;\n\tnode [shape=box, fontsize=10];\n")
+
+	for _, id := range ids {
+		record := g.records[id]
+
+		label := fmt.Sprintf("#%d gen %d\\nfitness %.3f", record.ID, record.Generation, record.Fitness)
+		if record.CrossoverPoint >= 0 {
+			label += fmt.Sprintf("\\ncrossover @%d", record.CrossoverPoint)
+		}
+		if len(record.Mutations) > 0 {
+			label += fmt.Sprintf("\\n%d mutations", len(record.Mutations))
+		}
+
+		style := ""
+		if record.Won {
+			style = ", style=filled, fillcolor=palegreen"
+		}
+		fmt.Fprintf(&out, "\tn%d [label=\"%s\"%s];\n", record.ID, label, style)
+
+		for _, parent := range record.Parents {
+			if included[parent] {
+				fmt.Fprintf(&out, "\tn%d -> n%d;\n", parent, record.ID)
+			}
+		}
+	}
+
+	out.WriteString("}\n")
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// Save Writes the genealogy to path, as DOT when it ends in .dot and as JSON otherwise.
+// With roots, only they and their ancestors are written.
+func (g *Genealogy) Save(path string, roots ...uint64) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	if strings.EqualFold(filepath.Ext(path), ".dot") {
+		err = g.WriteDOT(writer, roots...)
+	} else {
+		err = g.WriteJSON(writer, roots...)
+	}
+
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
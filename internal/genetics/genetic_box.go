@@ -2,8 +2,6 @@ package genetics
 
 import (
 	"math/rand"
-	"reflect"
-	"sort"
 
 	"github.com/pipawoz/go_genetic_algorithm/internal/population"
 	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
@@ -25,60 +23,117 @@ type GeneticBox struct {
 	AvgDistance    int
 	PopulationSize int
 	Population     []population.Box
+	History        []GenerationStats
+	Genealogy      *Genealogy // nil unless genealogyFile is set, as it keeps every individual of the run
+	Archive        NoveltyArchive
+	ParetoFront    []ParetoPoint
+	Islands        []*Island
+	observers      []Observer
+	bestFitness    float64
+	bestFound      bool
 }
 
+// NewGeneticBox creates a genetic box with a random population.
 func NewGeneticBox(populationSize int) *GeneticBox {
+	return NewSeededGeneticBox(populationSize, nil)
+}
+
+// NewSeededGeneticBox creates a genetic box whose population is seeded with the given genomes.
+func NewSeededGeneticBox(populationSize int, seeds []population.DNA) *GeneticBox {
 	g := &GeneticBox{}
-	dna := &population.DNA{}
-	g.Init(dna, populationSize)
+	g.Init(seeds, populationSize)
 	return g
 }
 
-// Init initializes the genetic box with the given DNA and population size.
-func (g *GeneticBox) Init(dna *population.DNA, populationSize int) {
+// Init initializes the genetic box with the given seed genomes and population size.
+// The population is filled by cycling through the seeds; every copy after the first
+// round has one non-zero gene mutated so the seeds are not cloned verbatim. Without seeds
+// the population is random.
+// The population is split into the islands set in the settings; when they set their own
+// population sizes, their sum replaces populationSize.
+func (g *GeneticBox) Init(seeds []population.DNA, populationSize int) {
 	g.Crashed = false
 	g.Won = false
 	g.WonTime = 0
 
-	// if dna.Chain == nil {
-	// 	dna.NewDNA(nil)
-	// }
-
-	g.PopulationSize = populationSize
 	g.Acceleration.X = 0
 	g.Acceleration.Y = 0
 	g.Velocity.X = 0
 	g.Velocity.Y = 0
 	g.Fitness = 0
+	g.Population = nil
+	g.Genealogy = nil
+	if utils.Settings.GenealogyFile != "" {
+		g.Genealogy = NewGenealogy()
+	}
+	g.Archive = NoveltyArchive{}
+	g.ParetoFront = nil
+	g.Islands = newIslands(populationSize)
+	g.bestFound = false
+
+	g.PopulationSize = 0
+	for _, island := range g.Islands {
+		g.PopulationSize += island.PopulationSize
+	}
 
-	for i := 0; i < g.PopulationSize; i++ {
+	mutable := make([][]int, len(seeds))
+	for i, seed := range seeds {
+		mutable[i] = mutableGenes(seed)
+	}
+
+	for _, island := range g.Islands {
+		for n := 0; n < island.PopulationSize; n++ {
+			i := len(g.Population)
+			individualDNA := population.DNA{}
 
-		individualDNA := population.DNA{}
-		individualDNA.NewDNA(nil) // Inicializa con genes aleatorios
+			if len(seeds) > 0 {
+				individualDNA = seeds[i%len(seeds)].Clone()
+				individualDNA.Extend(population.GeneLength)
+			} else {
+				individualDNA.NewDNA(nil)
+			}
+
+			box := population.NewBox(&individualDNA)
+			box.Lineage.Generation = 1
+			box.Island = island.Index
+			if len(seeds) > 0 && i >= len(seeds) {
+				if genes := mutable[i%len(seeds)]; len(genes) > 0 {
+					index := genes[rand.Intn(len(genes))]
+					gene := individualDNA.Chain[index]
+					box.MutateGene(index, gene.X == 0 || (gene.Y != 0 && rand.Intn(2) == 0))
+				}
+			}
 
-		// if dna.Chain == nil {
-		// }
+			g.Population = append(g.Population, *box)
+		}
+	}
+}
 
-		// if populationSize == 1 {
-		// box := population.NewBox(dna)
-		// g.Population = append(g.Population, *box)
-		// } else {
-		box := population.NewBox(&individualDNA)
-		g.Population = append(g.Population, *box)
+// mutableGenes Returns the indices of the genes of a seed that a mutation changes.
+// Mutations scale a gene, so the zero genes, the padding and the no-ops after a win, stay zero.
+func mutableGenes(seed population.DNA) []int {
+	var genes []int
+	for i, gene := range seed.Chain {
+		if gene.X != 0 || gene.Y != 0 {
+			genes = append(genes, i)
+		}
 	}
+
+	return genes
 }
 
 // GetBestBox Returns the best box in the population.
+// The population is not reordered, so the islands stay together.
 func (g *GeneticBox) GetBestBox() population.Box {
+	best := 0
 	for i := range g.Population {
 		g.Population[i].CalculateFitness()
+		if g.Population[i].Fitness > g.Population[best].Fitness {
+			best = i
+		}
 	}
 
-	sort.Slice(g.Population, func(i, j int) bool {
-		return g.Population[i].Fitness < g.Population[j].Fitness
-	})
-
-	return g.Population[len(g.Population)-1]
+	return g.Population[best]
 }
 
 // GetAvgFitness Returns the average fitness of the population.
@@ -120,76 +175,48 @@ func (g *GeneticBox) GetAvgTraveled() float64 {
 
 // NextGeneration Updates the population to the next generation.
 func (g *GeneticBox) NextGeneration() {
+	// Evaluation
+	// - Calculate the fitness (or novelty score) for all individuals
+	// - Record the statistics and the genealogy of the generation
+	// - Notify the observers of the wins, the deaths and the new best
 
-	// Selection
-	// Calculate the probability of each individual to pass.
-	// More fit individuals have a higher probability of continuing to the next generation.
-	// - Calculate the fitness for all individuals
-	// - Calculate the total fitness of all individuals and then calculate the probability
-	// of each individual to pass
-	// - Randomly choose individuals from the list, creating a new genetic pool, and add them to a list
-
-	for i := range g.Population {
-		g.Population[i].CalculateFitness()
-	}
+	g.evaluate()
 
 	g.AvgFitness = g.GetAvgFitness()
 	g.AvgDistance = g.GetAvgDistance()
+	g.History = append(g.History, g.computeStats())
 
-	var newSelection = []population.Box{}
-
-	totalFitness := 0.0
-	for i := range g.Population {
-		totalFitness += g.Population[i].Fitness
-	}
-
-	probabilityOfSelection := []float64{}
-	for i := range g.Population {
-		probabilityOfSelection = append(probabilityOfSelection, g.Population[i].Fitness/totalFitness)
-	}
-
-	for range g.Population {
-		selection := rand.Float64()
-		cumulativeProbability := 0.0
-		for i, individualProbability := range probabilityOfSelection {
-			cumulativeProbability += individualProbability
-			if selection <= cumulativeProbability {
-				newSelection = append(newSelection, g.Population[i])
-				break
-			}
+	if g.Genealogy != nil {
+		for i := range g.Population {
+			g.Genealogy.Record(&g.Population[i])
 		}
 	}
 
-	// Crossover
-	// - Randomly select parent A and parent B from the list after selection
-	// - If they are the same individual, choose another
-	// - Perform the crossover and obtain 2 offspring
-	// - Save the offspring in a new list, do not save the parents (they die)
-
-	crossoverList := []population.Box{}
-	for i := 1; i < g.PopulationSize; i += 2 {
-		if len(newSelection) < 2 {
-			break
-		}
-
-		parentA := newSelection[rand.Intn(len(newSelection))]
-		parentB := newSelection[rand.Intn(len(newSelection))]
+	g.notifyEvaluated()
 
-		if reflect.DeepEqual(parentA, parentB) {
-			i--
-			continue
-		}
+	// Migration
+	// - Every few generations the best individuals of each island replace the worst ones of its neighbors
 
-		offspringA, offspringB := parentA.Crossover(parentB)
-		crossoverList = append(crossoverList, offspringA)
-		crossoverList = append(crossoverList, offspringB)
+	if g.migrationDue() {
+		g.History[len(g.History)-1].Migrants = g.migrate()
 	}
 
-	// Mutation
-	// - Perform the mutation for each individual
+	// Selection, crossover and mutation, on every island with its own settings
 	// - Each individual will mutate a certain configurable percentage with a random probability
-	for i := range crossoverList {
-		crossoverList[i].Mutate()
+	var crossoverList []population.Box
+	g.ParetoFront = g.ParetoFront[:0]
+	for k, r := range g.islandRanges() {
+		island := g.Islands[k]
+		offspring := island.breed(g.Population[r.start:r.end])
+
+		for i := range offspring {
+			offspring[i].Lineage.Generation = len(g.History) + 1
+			offspring[i].Island = k
+		}
+
+		crossoverList = append(crossoverList, offspring...)
+		g.ParetoFront = append(g.ParetoFront, island.ParetoFront...)
+		g.History[len(g.History)-1].Species += len(island.Species)
 	}
 
 	// Replace the population with the new generation
@@ -200,4 +227,22 @@ func (g *GeneticBox) NextGeneration() {
 		g.Population[i].Reset()
 	}
 
+	g.notifyGenerationEnd()
+}
+
+// breed Returns the mutated offspring of the individuals of the island, with the configured algorithm.
+func (island *Island) breed(boxes []population.Box) []population.Box {
+	switch utils.DNASettings.Algorithm {
+	case utils.AlgorithmNSGA2:
+		return island.breedNSGA2(boxes)
+	case utils.AlgorithmSpecies:
+		return island.breedSpecies(boxes)
+	default:
+		return island.breedRoulette(boxes)
+	}
+}
+
+// breedRoulette Selects parents with roulette-wheel selection and returns their mutated offspring.
+func (island *Island) breedRoulette(boxes []population.Box) []population.Box {
+	return island.gaBreed(boxes, island.PopulationSize)
 }
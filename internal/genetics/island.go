@@ -0,0 +1,216 @@
+package genetics
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// Island is a subpopulation of the island model, evolving with its own settings.
+// The individuals of an island are kept together in GeneticBox.Population,
+// in the order of the islands, and carry its index in Box.Island.
+type Island struct {
+	Index          int
+	PopulationSize int
+	MutationRate   float64
+	CrossoverRate  float64
+	ParetoFront    []ParetoPoint
+	Species        []*Species
+	// inherit makes the island follow the global rates, which can be tuned during the run.
+	inherit bool
+	// parents are the individuals kept by NSGA-II from the previous step.
+	parents []population.Box
+	// nextSpecies is the ID of the last species created on the island.
+	nextSpecies int
+}
+
+// islandRange is the range of GeneticBox.Population holding an island.
+type islandRange struct {
+	start, end int
+}
+
+// newIslands Creates the islands set in the settings. With islands, every island has its own
+// population size and rates; with islandCount the population is split evenly and the islands
+// share the global rates. Otherwise there is a single island holding the whole population.
+func newIslands(populationSize int) []*Island {
+	settings := utils.DNASettings
+
+	var islands []*Island
+	switch {
+	case len(settings.Islands) > 0:
+		for i, island := range settings.Islands {
+			islands = append(islands, &Island{
+				Index:          i,
+				PopulationSize: island.PopulationSize,
+				MutationRate:   island.MutationRate,
+				CrossoverRate:  island.CrossoverRate,
+			})
+		}
+	case settings.IslandCount > 1:
+		for i := 0; i < settings.IslandCount; i++ {
+			size := populationSize / settings.IslandCount
+			if i == settings.IslandCount-1 {
+				size = populationSize - size*i
+			}
+			islands = append(islands, &Island{Index: i, PopulationSize: size, inherit: true})
+		}
+	default:
+		islands = append(islands, &Island{PopulationSize: populationSize, inherit: true})
+	}
+
+	return islands
+}
+
+// mutationRate Returns the mutation rate of the island.
+func (island *Island) mutationRate() float64 {
+	if island.inherit {
+		return utils.DNASettings.MutationRate
+	}
+
+	return island.MutationRate
+}
+
+// crossoverRate Returns the crossover rate of the island.
+func (island *Island) crossoverRate() float64 {
+	if island.inherit {
+		return utils.DNASettings.CrossoverRate
+	}
+
+	return island.CrossoverRate
+}
+
+// islandRanges Returns the range of the population held by every island.
+func (g *GeneticBox) islandRanges() []islandRange {
+	ranges := make([]islandRange, len(g.Islands))
+	start := 0
+	for k := range g.Islands {
+		end := start
+		for end < len(g.Population) && g.Population[end].Island == k {
+			end++
+		}
+		ranges[k] = islandRange{start, end}
+		start = end
+	}
+
+	return ranges
+}
+
+// IslandPopulation Returns the individuals of an island. The slice shares the population.
+func (g *GeneticBox) IslandPopulation(index int) []population.Box {
+	r := g.islandRanges()[index]
+	return g.Population[r.start:r.end]
+}
+
+// migrationDue Reports whether the evaluated generation ends with a migration.
+func (g *GeneticBox) migrationDue() bool {
+	interval := utils.DNASettings.MigrationInterval
+	return len(g.Islands) > 1 && interval > 0 && utils.DNASettings.Migrants > 0 && len(g.History)%interval == 0
+}
+
+// migrate Copies the best individuals of every island to its neighbors in the migration topology,
+// where they replace the worst individuals before the selection. At most half of an island
+// is replaced, so a fully connected topology cannot wipe out the local individuals.
+// Returns: the number of individuals that migrated.
+func (g *GeneticBox) migrate() int {
+	ranges := g.islandRanges()
+	migrants := utils.DNASettings.Migrants
+
+	emigrants := make([][]population.Box, len(ranges))
+	for k, r := range ranges {
+		island := g.Population[r.start:r.end]
+		for _, i := range byFitness(island)[:min(migrants, len(island))] {
+			emigrant := island[i]
+			emigrant.Genes = emigrant.Genes.Clone()
+			emigrants[k] = append(emigrants[k], emigrant)
+		}
+	}
+
+	incoming := make([][]population.Box, len(ranges))
+	for k := range ranges {
+		for _, destination := range migrationDestinations(k, len(ranges), utils.DNASettings.MigrationTopology) {
+			incoming[destination] = append(incoming[destination], emigrants[k]...)
+		}
+	}
+
+	migrated := 0
+	for k, r := range ranges {
+		island := g.Population[r.start:r.end]
+		worst := byFitness(island)
+		for n := 0; n < len(incoming[k]) && n < len(island)/2; n++ {
+			i := worst[len(worst)-1-n]
+			island[i] = incoming[k][n]
+			island[i].Island = k
+			migrated++
+		}
+	}
+
+	return migrated
+}
+
+// byFitness Returns the indexes of the boxes from the fittest to the least fit.
+func byFitness(boxes []population.Box) []int {
+	order := make([]int, len(boxes))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool { return boxes[order[a]].Fitness > boxes[order[b]].Fitness })
+	return order
+}
+
+// migrationDestinations Returns the islands receiving the migrants of an island.
+func migrationDestinations(island, islands int, topology string) []int {
+	switch topology {
+	case utils.TopologyFull:
+		var destinations []int
+		for k := 0; k < islands; k++ {
+			if k != island {
+				destinations = append(destinations, k)
+			}
+		}
+		return destinations
+	case utils.TopologyRandom:
+		return []int{(island + 1 + rand.Intn(islands-1)) % islands}
+	default:
+		return []int{(island + 1) % islands}
+	}
+}
+
+// Simulate Runs the current generation until every box has died, won or used the move limit.
+// When parallel is set every island is simulated in its own goroutine; the islands hold
+// disjoint parts of the population, so they never touch the same box.
+func (g *GeneticBox) Simulate(walls []utils.Obstacle, moveLimit int, parallel bool) {
+	var wg sync.WaitGroup
+	for _, r := range g.islandRanges() {
+		island := g.Population[r.start:r.end]
+		if !parallel {
+			simulateBoxes(island, walls, moveLimit)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			simulateBoxes(island, walls, moveLimit)
+		}()
+	}
+	wg.Wait()
+}
+
+// simulateBoxes Moves every box frame by frame, as the game loop does, until it stops.
+// The time spent on every box is kept in its EvalTime.
+func simulateBoxes(boxes []population.Box, walls []utils.Obstacle, moveLimit int) {
+	for i := range boxes {
+		box := &boxes[i]
+		start := time.Now()
+		for counter := 0; counter <= moveLimit && box.IsAlive && !box.Won; counter++ {
+			box.Update(counter)
+			box.CheckCollision(walls)
+		}
+		box.EvalTime = time.Since(start)
+	}
+}
@@ -0,0 +1,142 @@
+package genetics
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// noveltyArchiveAdditions is the number of most novel individuals added to the archive every generation.
+const noveltyArchiveAdditions = 3
+
+// NoveltyArchive holds the behaviors of past individuals, so novelty is measured
+// against the history of the search and not only the current population.
+type NoveltyArchive struct {
+	Behaviors [][]utils.Vector
+}
+
+// behavior Returns the behavior descriptor of a box: its sampled trajectory followed by its final position.
+func behavior(box *population.Box) []utils.Vector {
+	descriptor := make([]utils.Vector, 0, len(box.Trajectory)+1)
+	descriptor = append(descriptor, box.Trajectory...)
+	return append(descriptor, box.Position)
+}
+
+// behaviorDistance Returns the mean distance between the points of two descriptors.
+// The shorter descriptor is padded with its last point, i.e. a box stays where it died.
+func behaviorDistance(a, b []utils.Vector) float64 {
+	length := max(len(a), len(b))
+
+	total := 0.0
+	for i := 0; i < length; i++ {
+		pa, pb := a[min(i, len(a)-1)], b[min(i, len(b)-1)]
+		dx, dy := float64(pa.X-pb.X), float64(pa.Y-pb.Y)
+		total += math.Sqrt(dx*dx + dy*dy)
+	}
+
+	return total / float64(length)
+}
+
+// evaluate Scores the population according to the fitness mode.
+// In novelty and hybrid modes the distance fitness is replaced by the novelty score.
+func (g *GeneticBox) evaluate() {
+	for i := range g.Population {
+		g.Population[i].CalculateFitness()
+	}
+
+	mode := utils.DNASettings.FitnessMode
+	if mode != utils.FitnessNovelty && mode != utils.FitnessHybrid {
+		return
+	}
+
+	behaviors := make([][]utils.Vector, len(g.Population))
+	for i := range g.Population {
+		behaviors[i] = behavior(&g.Population[i])
+	}
+
+	k := max(utils.DNASettings.NoveltyNeighbors, 1)
+	for i := range g.Population {
+		g.Population[i].Novelty = g.novelty(i, behaviors, k)
+	}
+
+	if mode == utils.FitnessHybrid {
+		g.mixNovelty(utils.DNASettings.NoveltyWeight)
+	} else {
+		for i := range g.Population {
+			g.Population[i].Fitness = g.Population[i].Novelty
+		}
+	}
+
+	g.archiveMostNovel(behaviors)
+}
+
+// novelty Returns the mean distance from the behavior of an individual to its k nearest
+// neighbors among the rest of the population and the archive.
+func (g *GeneticBox) novelty(index int, behaviors [][]utils.Vector, k int) float64 {
+	distances := make([]float64, 0, len(behaviors)+len(g.Archive.Behaviors))
+	for j, other := range behaviors {
+		if j != index {
+			distances = append(distances, behaviorDistance(behaviors[index], other))
+		}
+	}
+
+	for _, other := range g.Archive.Behaviors {
+		distances = append(distances, behaviorDistance(behaviors[index], other))
+	}
+
+	if len(distances) == 0 {
+		return 0
+	}
+
+	sort.Float64s(distances)
+	k = min(k, len(distances))
+
+	total := 0.0
+	for _, distance := range distances[:k] {
+		total += distance
+	}
+
+	return total / float64(k)
+}
+
+// mixNovelty Replaces the fitness with a weighted sum of the fitness and the novelty,
+// both scaled to [0, 1] within the generation so the weight is meaningful.
+func (g *GeneticBox) mixNovelty(weight float64) {
+	minFitness, maxFitness := math.Inf(1), math.Inf(-1)
+	maxNovelty := 0.0
+	for i := range g.Population {
+		minFitness = math.Min(minFitness, g.Population[i].Fitness)
+		maxFitness = math.Max(maxFitness, g.Population[i].Fitness)
+		maxNovelty = math.Max(maxNovelty, g.Population[i].Novelty)
+	}
+
+	for i := range g.Population {
+		fitness, novelty := 0.0, 0.0
+		if maxFitness > minFitness {
+			fitness = (g.Population[i].Fitness - minFitness) / (maxFitness - minFitness)
+		}
+		if maxNovelty > 0 {
+			novelty = g.Population[i].Novelty / maxNovelty
+		}
+
+		g.Population[i].Fitness = (1-weight)*fitness + weight*novelty
+	}
+}
+
+// archiveMostNovel Adds the behaviors of the most novel individuals to the archive.
+func (g *GeneticBox) archiveMostNovel(behaviors [][]utils.Vector) {
+	order := make([]int, len(g.Population))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.Slice(order, func(a, b int) bool {
+		return g.Population[order[a]].Novelty > g.Population[order[b]].Novelty
+	})
+
+	for _, i := range order[:min(noveltyArchiveAdditions, len(order))] {
+		g.Archive.Behaviors = append(g.Archive.Behaviors, behaviors[i])
+	}
+}
@@ -0,0 +1,202 @@
+package genetics
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+	"github.com/pipawoz/go_genetic_algorithm/pkg/ga"
+)
+
+// objectiveCount is the number of objectives optimized by NSGA-II.
+const objectiveCount = 4
+
+// objectives are the values NSGA-II minimizes for a box:
+// the distance to the goal, the frames needed to reach it, the path length
+// and the negated clearance, so that keeping away from walls is rewarded.
+type objectives [objectiveCount]float64
+
+// ParetoPoint is an individual of the Pareto front with its objective values.
+type ParetoPoint struct {
+	ID        uint64  `json:"id"`
+	Island    int     `json:"island"`
+	Distance  float64 `json:"distance"`
+	Frames    int     `json:"frames"`
+	Traveled  float64 `json:"traveled"`
+	Clearance float64 `json:"clearance"`
+	Won       bool    `json:"won"`
+}
+
+// boxObjectives Returns the objectives of an evaluated box.
+// Boxes that did not reach the goal count as using every available move.
+func boxObjectives(box *population.Box) objectives {
+	distance, frames := box.Dist, float64(utils.MaxMoves)
+	if box.Won {
+		distance, frames = 0, float64(box.Frames)
+	}
+
+	return objectives{distance, frames, box.Traveled, -box.Clearance}
+}
+
+// dominates Reports whether a is no worse than b in every objective and better in at least one.
+func dominates(a, b objectives) bool {
+	better := false
+	for i := range a {
+		if a[i] > b[i] {
+			return false
+		}
+		if a[i] < b[i] {
+			better = true
+		}
+	}
+
+	return better
+}
+
+// nonDominatedSort Splits the individuals into Pareto fronts, the first one being non-dominated.
+// It also returns the rank (front index) of every individual.
+func nonDominatedSort(objs []objectives) ([][]int, []int) {
+	dominatedBy := make([]int, len(objs))
+	dominating := make([][]int, len(objs))
+	rank := make([]int, len(objs))
+
+	var fronts [][]int
+	var front []int
+	for p := range objs {
+		for q := range objs {
+			if dominates(objs[p], objs[q]) {
+				dominating[p] = append(dominating[p], q)
+			} else if dominates(objs[q], objs[p]) {
+				dominatedBy[p]++
+			}
+		}
+
+		if dominatedBy[p] == 0 {
+			front = append(front, p)
+		}
+	}
+
+	for len(front) > 0 {
+		fronts = append(fronts, front)
+
+		var next []int
+		for _, p := range front {
+			for _, q := range dominating[p] {
+				dominatedBy[q]--
+				if dominatedBy[q] == 0 {
+					rank[q] = len(fronts)
+					next = append(next, q)
+				}
+			}
+		}
+		front = next
+	}
+
+	return fronts, rank
+}
+
+// assignCrowding Sets the crowding distance of the individuals of a front.
+// The individuals at the ends of every objective get an infinite distance so they are always kept.
+func assignCrowding(front []int, objs []objectives, crowding []float64) {
+	for _, i := range front {
+		crowding[i] = 0
+	}
+
+	sorted := append([]int(nil), front...)
+	for m := 0; m < objectiveCount; m++ {
+		sort.Slice(sorted, func(a, b int) bool { return objs[sorted[a]][m] < objs[sorted[b]][m] })
+
+		low, high := objs[sorted[0]][m], objs[sorted[len(sorted)-1]][m]
+		crowding[sorted[0]] = math.Inf(1)
+		crowding[sorted[len(sorted)-1]] = math.Inf(1)
+
+		if high == low {
+			continue
+		}
+
+		for k := 1; k < len(sorted)-1; k++ {
+			crowding[sorted[k]] += (objs[sorted[k+1]][m] - objs[sorted[k-1]][m]) / (high - low)
+		}
+	}
+}
+
+// breedNSGA2 Runs an NSGA-II step on the island and returns the mutated offspring to evaluate next.
+// The evaluated offspring are merged with the parents kept from the previous step,
+// the best PopulationSize individuals by rank and crowding distance become the new parents,
+// and the offspring are bred from them with binary tournaments.
+func (island *Island) breedNSGA2(boxes []population.Box) []population.Box {
+	combined := append(append([]population.Box(nil), island.parents...), boxes...)
+
+	objs := make([]objectives, len(combined))
+	for i := range combined {
+		objs[i] = boxObjectives(&combined[i])
+	}
+
+	fronts, rank := nonDominatedSort(objs)
+	crowding := make([]float64, len(combined))
+	for _, front := range fronts {
+		assignCrowding(front, objs, crowding)
+	}
+
+	island.ParetoFront = island.ParetoFront[:0]
+	for _, i := range fronts[0] {
+		island.ParetoFront = append(island.ParetoFront, ParetoPoint{
+			ID:        combined[i].Lineage.ID,
+			Island:    island.Index,
+			Distance:  objs[i][0],
+			Frames:    int(objs[i][1]),
+			Traveled:  objs[i][2],
+			Clearance: -objs[i][3],
+			Won:       combined[i].Won,
+		})
+	}
+
+	// Environmental selection: whole fronts while they fit, then the least crowded of the next one.
+	var selected []int
+	for _, front := range fronts {
+		if len(selected)+len(front) > island.PopulationSize {
+			sorted := append([]int(nil), front...)
+			sort.Slice(sorted, func(a, b int) bool { return crowding[sorted[a]] > crowding[sorted[b]] })
+			selected = append(selected, sorted[:island.PopulationSize-len(selected)]...)
+			break
+		}
+		selected = append(selected, front...)
+	}
+
+	island.parents = island.parents[:0]
+	for _, i := range selected {
+		island.parents = append(island.parents, combined[i])
+	}
+
+	// The offspring are bred by the ga package with binary tournaments on a score ordering the
+	// parents by rank, then by crowding distance: lower rank wins, then larger crowding distance.
+	scores := make([]float64, len(island.parents))
+	for k, i := range selected {
+		scores[k] = crowdingScore(rank[i], crowding[i])
+	}
+
+	return island.gaBreedWith(island.parents, scores, ga.Tournament(2), island.PopulationSize)
+}
+
+// crowdingScore Returns a score that is higher for a lower rank and, within a rank,
+// for a larger crowding distance. The crowding distance only moves the score within
+// half a rank, so it never outweighs the rank.
+func crowdingScore(rank int, crowding float64) float64 {
+	closeness := 0.5
+	if !math.IsInf(crowding, 1) {
+		closeness = 0.5 * crowding / (1 + crowding)
+	}
+
+	return closeness - float64(rank)
+}
+
+// WriteParetoFront Writes the Pareto front of a generation as a line of JSON.
+func WriteParetoFront(w io.Writer, generation int, front []ParetoPoint) error {
+	return json.NewEncoder(w).Encode(struct {
+		Generation int           `json:"generation"`
+		Front      []ParetoPoint `json:"front"`
+	}{generation, front})
+}
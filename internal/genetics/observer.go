@@ -0,0 +1,125 @@
+package genetics
+
+import (
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// Observer is notified of the events of the evolution loop.
+// The events are sent from the goroutine that calls the methods of GeneticBox, one at a time,
+// so observers need no locking. Embed BaseObserver to implement only some of the methods.
+type Observer interface {
+	// OnGenerationStart is called before a generation is simulated.
+	OnGenerationStart(generation int, boxes []population.Box)
+	// OnEvaluated is called with every box once a generation is evaluated, before it is bred.
+	OnEvaluated(generation int, boxes []population.Box)
+	// OnGenerationEnd is called once a generation is evaluated and the next one is bred.
+	OnGenerationEnd(stats GenerationStats)
+	// OnWin is called for every box that reached the goal, when its generation is evaluated.
+	OnWin(generation int, box *population.Box)
+	// OnDeath is called for every box that died, when its generation is evaluated.
+	// Dead boxes stop moving, so position is where the box died.
+	OnDeath(generation int, box *population.Box, cause population.DeathCause, position utils.Vector)
+	// OnNewBest is called when the best box of a generation beats the best fitness found so far.
+	OnNewBest(generation int, box *population.Box)
+	// OnRunFinished is called once after the last generation.
+	OnRunFinished(summary RunSummary)
+}
+
+// BaseObserver implements every method of Observer with no effect.
+type BaseObserver struct{}
+
+// OnGenerationStart Does nothing.
+func (BaseObserver) OnGenerationStart(int, []population.Box) {}
+
+// OnEvaluated Does nothing.
+func (BaseObserver) OnEvaluated(int, []population.Box) {}
+
+// OnGenerationEnd Does nothing.
+func (BaseObserver) OnGenerationEnd(GenerationStats) {}
+
+// OnWin Does nothing.
+func (BaseObserver) OnWin(int, *population.Box) {}
+
+// OnDeath Does nothing.
+func (BaseObserver) OnDeath(int, *population.Box, population.DeathCause, utils.Vector) {}
+
+// OnNewBest Does nothing.
+func (BaseObserver) OnNewBest(int, *population.Box) {}
+
+// OnRunFinished Does nothing.
+func (BaseObserver) OnRunFinished(RunSummary) {}
+
+// AddObserver Registers observers, which are notified in the order they were added.
+func (g *GeneticBox) AddObserver(observers ...Observer) {
+	g.observers = append(g.observers, observers...)
+}
+
+// StartGeneration Notifies the observers that the current population is about to be simulated.
+func (g *GeneticBox) StartGeneration() {
+	for _, observer := range g.observers {
+		observer.OnGenerationStart(len(g.History)+1, g.Population)
+	}
+}
+
+// Finish Notifies the observers that the run is over.
+func (g *GeneticBox) Finish() {
+	summary := g.Summary()
+	for _, observer := range g.observers {
+		observer.OnRunFinished(summary)
+	}
+}
+
+// ResetBest Forgets the best fitness found so far, so the next generation reports a new best.
+// Fitness is not comparable between levels, so it is reset when the level changes.
+func (g *GeneticBox) ResetBest() {
+	g.bestFound = false
+}
+
+// notifyEvaluated Notifies the observers of the generation that was just evaluated,
+// of its wins, its deaths and its new best.
+func (g *GeneticBox) notifyEvaluated() {
+	if len(g.observers) == 0 {
+		return
+	}
+
+	generation := len(g.History)
+	for _, observer := range g.observers {
+		observer.OnEvaluated(generation, g.Population)
+	}
+
+	best := -1
+	for i := range g.Population {
+		box := &g.Population[i]
+		for _, observer := range g.observers {
+			switch {
+			case box.Won:
+				observer.OnWin(generation, box)
+			case !box.IsAlive:
+				observer.OnDeath(generation, box, box.DeathCause, box.Position)
+			}
+		}
+
+		if best < 0 || box.Fitness > g.Population[best].Fitness {
+			best = i
+		}
+	}
+
+	if best < 0 || (g.bestFound && g.Population[best].Fitness <= g.bestFitness) {
+		return
+	}
+
+	g.bestFound = true
+	g.bestFitness = g.Population[best].Fitness
+	for _, observer := range g.observers {
+		observer.OnNewBest(generation, &g.Population[best])
+	}
+}
+
+// notifyGenerationEnd Notifies the observers that the last generation of History is over.
+func (g *GeneticBox) notifyGenerationEnd() {
+	stats := g.History[len(g.History)-1]
+	for _, observer := range g.observers {
+		observer.OnGenerationEnd(stats)
+	}
+}
@@ -0,0 +1,185 @@
+package genetics
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// Species is a group of similar individuals of an island that share their fitness.
+type Species struct {
+	ID int
+	// Representative is the individual the others are compared to, taken from the previous generation.
+	Representative population.Box
+	Size           int
+	BestFitness    float64
+	// Stagnant is the number of generations since BestFitness last improved.
+	Stagnant int
+	members  []int
+}
+
+// speciesDistance Returns the distance between two individuals with the configured metric.
+func speciesDistance(a, b *population.Box) float64 {
+	if utils.DNASettings.SpeciesMetric == utils.SpeciesBehavior {
+		return behaviorDistance(behavior(a), behavior(b))
+	}
+
+	return chainDistance(a.Genes.Chain, b.Genes.Chain)
+}
+
+// breedSpecies Groups the individuals of the island into species and breeds every species apart.
+// Fitness is shared within each species, so a species gets offspring in proportion to the mean
+// fitness of its members and a single lineage cannot take over the whole island.
+// Species that have not improved for speciesStagnation generations are removed,
+// except the one holding the best individual.
+func (island *Island) breedSpecies(boxes []population.Box) []population.Box {
+	island.speciate(boxes)
+	island.removeStagnant(boxes)
+
+	counts := island.allocateOffspring(boxes)
+
+	var offspring []population.Box
+	for s, species := range island.Species {
+		members := make([]population.Box, len(species.members))
+		for m, i := range species.members {
+			members[m] = boxes[i]
+		}
+
+		bred := island.gaBreed(members, counts[s])
+		for k := range bred {
+			bred[k].Species = species.ID
+		}
+		offspring = append(offspring, bred...)
+
+		// A random member represents the species in the next generation, as in NEAT.
+		representative := members[rand.Intn(len(members))]
+		representative.Genes = representative.Genes.Clone()
+		species.Representative = representative
+	}
+
+	return offspring
+}
+
+// speciate Assigns every individual to the first species whose representative is closer
+// than speciesThreshold, creating a new species when there is none. Empty species are dropped.
+func (island *Island) speciate(boxes []population.Box) {
+	threshold := utils.DNASettings.SpeciesThreshold
+
+	for _, species := range island.Species {
+		species.members = species.members[:0]
+	}
+
+	for i := range boxes {
+		placed := false
+		for _, species := range island.Species {
+			if speciesDistance(&boxes[i], &species.Representative) < threshold {
+				species.members = append(species.members, i)
+				placed = true
+				break
+			}
+		}
+
+		if !placed {
+			representative := boxes[i]
+			representative.Genes = representative.Genes.Clone()
+			island.nextSpecies++
+			island.Species = append(island.Species, &Species{
+				ID:             island.nextSpecies,
+				Representative: representative,
+				BestFitness:    math.Inf(-1),
+				members:        []int{i},
+			})
+		}
+	}
+
+	kept := island.Species[:0]
+	for _, species := range island.Species {
+		if len(species.members) > 0 {
+			species.Size = len(species.members)
+			kept = append(kept, species)
+		}
+	}
+	island.Species = kept
+}
+
+// removeStagnant Updates the best fitness of every species and removes the stagnant ones.
+func (island *Island) removeStagnant(boxes []population.Box) {
+	var best *Species
+	bestFitness := math.Inf(-1)
+
+	for _, species := range island.Species {
+		fitness := math.Inf(-1)
+		for _, i := range species.members {
+			fitness = math.Max(fitness, boxes[i].Fitness)
+		}
+
+		if fitness > species.BestFitness {
+			species.BestFitness = fitness
+			species.Stagnant = 0
+		} else {
+			species.Stagnant++
+		}
+
+		if fitness > bestFitness || best == nil {
+			best, bestFitness = species, fitness
+		}
+	}
+
+	limit := utils.DNASettings.SpeciesStagnation
+	if limit <= 0 {
+		return
+	}
+
+	kept := island.Species[:0]
+	for _, species := range island.Species {
+		if species.Stagnant < limit || species == best {
+			kept = append(kept, species)
+		}
+	}
+	island.Species = kept
+}
+
+// allocateOffspring Returns the number of offspring of every species, proportional to the
+// mean fitness of its members, which is the sum of their shared fitness. The counts add up
+// to the population size of the island; the remainders go to the largest fractions.
+func (island *Island) allocateOffspring(boxes []population.Box) []int {
+	shares := make([]float64, len(island.Species))
+	total := 0.0
+	for s, species := range island.Species {
+		for _, i := range species.members {
+			shares[s] += math.Max(boxes[i].Fitness, 0) / float64(len(species.members))
+		}
+		total += shares[s]
+	}
+
+	// Without any fitness the offspring follow the size of the species.
+	if total <= 0 {
+		total = 0
+		for s, species := range island.Species {
+			shares[s] = float64(len(species.members))
+			total += shares[s]
+		}
+	}
+
+	counts := make([]int, len(island.Species))
+	order := make([]int, len(island.Species))
+	assigned := 0
+	for s := range shares {
+		exact := shares[s] / total * float64(island.PopulationSize)
+		counts[s] = int(exact)
+		shares[s] = exact - float64(counts[s])
+		assigned += counts[s]
+		order[s] = s
+	}
+
+	sort.SliceStable(order, func(a, b int) bool { return shares[order[a]] > shares[order[b]] })
+	for n := 0; assigned < island.PopulationSize; n++ {
+		counts[order[n%len(order)]]++
+		assigned++
+	}
+
+	return counts
+}
@@ -0,0 +1,104 @@
+package genetics
+
+import "math"
+
+// GenerationStats holds the statistics of an evaluated generation.
+type GenerationStats struct {
+	Generation   int       `json:"generation"`
+	AvgFitness   float64   `json:"avgFitness"`
+	BestFitness  float64   `json:"bestFitness"`
+	WorstFitness float64   `json:"worstFitness"`
+	AvgDistance  int       `json:"avgDistance"`
+	Alive        int       `json:"alive"`
+	Dead         int       `json:"dead"`
+	Won          int       `json:"won"`
+	AvgNovelty   float64   `json:"avgNovelty"`
+	ArchiveSize  int       `json:"archiveSize"`
+	Diversity    Diversity `json:"diversity"`
+	IslandBest   []float64 `json:"islandBest,omitempty"`
+	Migrants     int       `json:"migrants"`
+	Species      int       `json:"species"`
+}
+
+// computeStats Computes the statistics of the population once its fitness is calculated.
+func (g *GeneticBox) computeStats() GenerationStats {
+	stats := GenerationStats{
+		Generation:   len(g.History) + 1,
+		AvgFitness:   g.AvgFitness,
+		BestFitness:  math.Inf(-1),
+		WorstFitness: math.Inf(1),
+		AvgDistance:  g.AvgDistance,
+		ArchiveSize:  len(g.Archive.Behaviors),
+		Diversity:    computeDiversity(g.Population),
+	}
+
+	if len(g.Islands) > 1 {
+		stats.IslandBest = make([]float64, len(g.Islands))
+		for k := range stats.IslandBest {
+			stats.IslandBest[k] = math.Inf(-1)
+		}
+	}
+
+	for i := range g.Population {
+		individual := &g.Population[i]
+
+		if stats.IslandBest != nil {
+			stats.IslandBest[individual.Island] = math.Max(stats.IslandBest[individual.Island], individual.Fitness)
+		}
+
+		stats.BestFitness = math.Max(stats.BestFitness, individual.Fitness)
+		stats.WorstFitness = math.Min(stats.WorstFitness, individual.Fitness)
+		stats.AvgNovelty += individual.Novelty / float64(len(g.Population))
+
+		switch {
+		case individual.Won:
+			stats.Won++
+		case individual.IsAlive:
+			stats.Alive++
+		default:
+			stats.Dead++
+		}
+	}
+
+	return stats
+}
+
+// WinRate Returns the fraction of the population that reached the goal.
+func (s GenerationStats) WinRate() float64 {
+	total := s.Alive + s.Dead + s.Won
+	if total == 0 {
+		return 0
+	}
+
+	return float64(s.Won) / float64(total)
+}
+
+// RunSummary is the outcome of a run.
+type RunSummary struct {
+	Generations int `json:"generations"`
+	// FirstWin is the first generation in which a box reached the goal, 0 if none did.
+	FirstWin    int     `json:"firstWin"`
+	BestFitness float64 `json:"bestFitness"`
+	WinRate     float64 `json:"winRate"`
+}
+
+// Summary Returns the outcome of the generations evaluated so far.
+// The best fitness and the win rate are the ones of the last generation.
+func (g *GeneticBox) Summary() RunSummary {
+	summary := RunSummary{Generations: len(g.History)}
+
+	for _, stats := range g.History {
+		if stats.Won > 0 {
+			summary.FirstWin = stats.Generation
+			break
+		}
+	}
+
+	if len(g.History) > 0 {
+		last := g.History[len(g.History)-1]
+		summary.BestFitness = last.BestFitness
+		summary.WinRate = last.WinRate()
+	}
+
+	return summary
+}
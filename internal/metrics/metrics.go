@@ -0,0 +1,199 @@
+// Package metrics exposes the progress of a run in the Prometheus text format.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/genetics"
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+)
+
+// contentType is the content type of the Prometheus text format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Buckets of the histograms, in seconds.
+var (
+	generationBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+	evaluationBuckets = []float64{0.00001, 0.00005, 0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.05, 0.1}
+)
+
+// histogram counts observations in cumulative buckets, as Prometheus histograms do.
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// newHistogram Creates a histogram with the given upper bounds, in increasing order.
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// observe Adds a value to the histogram.
+func (h *histogram) observe(value float64) {
+	for i, bound := range h.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// Collector follows a run as an observer of the evolution loop and serves its metrics.
+// The observer methods are called from the game loop and the scrapes from the server,
+// so the values are guarded by a mutex.
+type Collector struct {
+	genetics.BaseObserver
+
+	mu              sync.Mutex
+	generation      int
+	stats           genetics.GenerationStats
+	generationStart time.Time
+	generationTime  *histogram
+	evaluationTime  *histogram
+}
+
+// NewCollector Creates a collector with no observation.
+func NewCollector() *Collector {
+	return &Collector{
+		generationTime: newHistogram(generationBuckets),
+		evaluationTime: newHistogram(evaluationBuckets),
+	}
+}
+
+// OnGenerationStart Records the generation being simulated and starts its clock.
+func (c *Collector) OnGenerationStart(generation int, boxes []population.Box) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation = generation
+	c.generationStart = time.Now()
+}
+
+// OnEvaluated Records the time spent simulating every box. Boxes that were not timed are left out.
+func (c *Collector) OnEvaluated(generation int, boxes []population.Box) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range boxes {
+		if boxes[i].EvalTime > 0 {
+			c.evaluationTime.observe(boxes[i].EvalTime.Seconds())
+		}
+	}
+}
+
+// OnGenerationEnd Records the statistics and the wall time of the generation.
+func (c *Collector) OnGenerationEnd(stats genetics.GenerationStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats = stats
+	if !c.generationStart.IsZero() {
+		c.generationTime.observe(time.Since(c.generationStart).Seconds())
+		c.generationStart = time.Time{}
+	}
+}
+
+// WriteTo Writes the metrics in the Prometheus text format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	out := c.render()
+	return out.WriteTo(w)
+}
+
+// render Returns the metrics in the Prometheus text format, so they are not written
+// to a slow client while the mutex is held.
+func (c *Collector) render() *bytes.Buffer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var out bytes.Buffer
+
+	gauges := []struct {
+		name, help string
+		value      float64
+	}{
+		{"gga_generation", "Generation being simulated.", float64(c.generation)},
+		{"gga_generations_completed", "Number of generations evaluated.", float64(c.stats.Generation)},
+		{"gga_best_fitness", "Best fitness of the last generation.", c.stats.BestFitness},
+		{"gga_avg_fitness", "Average fitness of the last generation.", c.stats.AvgFitness},
+		{"gga_avg_distance", "Average distance to the goal of the last generation, in pixels.", float64(c.stats.AvgDistance)},
+		{"gga_win_rate", "Fraction of the last generation that reached the goal.", c.stats.WinRate()},
+		{"gga_diversity_genome_distance", "Mean distance between the genes of two genomes of the last generation.", c.stats.Diversity.GenomeDistance},
+		{"gga_diversity_positional_spread", "Root mean square distance of the final positions to their centroid, in pixels.", c.stats.Diversity.PositionalSpread},
+		{"gga_diversity_clusters", "Number of groups of final positions of the last generation.", float64(c.stats.Diversity.Clusters)},
+		{"gga_diversity_gene_entropy", "Normalized entropy of the gene angles of the last generation.", c.stats.Diversity.GeneEntropy},
+	}
+
+	for _, gauge := range gauges {
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", gauge.name, gauge.help, gauge.name, gauge.name, formatValue(gauge.value))
+	}
+
+	writeHistogram(&out, "gga_generation_duration_seconds", "Wall time of a generation, from its start until the next one is bred.", c.generationTime)
+	writeHistogram(&out, "gga_evaluation_duration_seconds", "Wall time spent simulating an individual. Only headless runs measure it.", c.evaluationTime)
+
+	return &out
+}
+
+// writeHistogram Writes a histogram with its cumulative buckets, its sum and its count.
+func writeHistogram(w io.Writer, name, help string, h *histogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for i, bound := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", name, formatValue(bound), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", name, formatValue(h.sum))
+	fmt.Fprintf(w, "%s_count %d\n", name, h.count)
+}
+
+// formatValue Formats a sample value, with the spelling of infinities and NaN of the text format.
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// ServeHTTP Serves the metrics to a scrape.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	c.WriteTo(w)
+}
+
+// Start Serves the metrics on addr under /metrics in the background.
+// The address is checked before returning; close the server to stop it.
+func (c *Collector) Start(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", c)
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			slog.Error("metrics server stopped", "err", err)
+		}
+	}()
+
+	slog.Info("metrics server started", "url", "http://"+listener.Addr().String()+"/metrics")
+	return server, nil
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/genetics"
+	"github.com/pipawoz/go_genetic_algorithm/internal/population"
+)
+
+// scrape Fetches the metrics of the collector over HTTP and returns the content type and
+// the samples by name, labels included. The exposition is checked to be well formed.
+func scrape(t *testing.T, c *Collector) (string, map[string]float64) {
+	t.Helper()
+
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+
+	samples := map[string]float64{}
+	typed := map[string]string{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if fields := strings.Fields(line); strings.HasPrefix(line, "# TYPE ") && len(fields) == 4 {
+			typed[fields[2]] = fields[3]
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, " ")
+		if !ok {
+			t.Fatalf("malformed sample %q", line)
+		}
+
+		family, _, _ := strings.Cut(name, "{")
+		family = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(family, "_bucket"), "_sum"), "_count")
+		if typed[family] == "" {
+			t.Errorf("sample %q has no TYPE line before it", line)
+		}
+
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			t.Fatalf("sample %q: %v", line, err)
+		}
+		samples[name] = parsed
+	}
+
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.Header.Get("Content-Type"), samples
+}
+
+func TestCollectorScrape(t *testing.T) {
+	c := NewCollector()
+
+	boxes := []population.Box{
+		{EvalTime: 30 * time.Microsecond},
+		{EvalTime: 200 * time.Microsecond},
+		{EvalTime: 200 * time.Millisecond},
+		{}, // not timed, as in windowed runs
+	}
+
+	c.OnGenerationStart(3, boxes)
+	c.OnEvaluated(3, boxes)
+	c.OnGenerationEnd(genetics.GenerationStats{
+		Generation:  3,
+		AvgFitness:  0.25,
+		BestFitness: 0.75,
+		AvgDistance: 420,
+		Dead:        3,
+		Won:         1,
+	})
+
+	contentType, samples := scrape(t, c)
+	if contentType != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("content type %q", contentType)
+	}
+
+	gauges := map[string]float64{
+		"gga_generation":            3,
+		"gga_generations_completed": 3,
+		"gga_best_fitness":          0.75,
+		"gga_avg_fitness":           0.25,
+		"gga_avg_distance":          420,
+		"gga_win_rate":              0.25,
+	}
+	for name, want := range gauges {
+		if got, ok := samples[name]; !ok || got != want {
+			t.Errorf("%s = %v (present %t), want %v", name, got, ok, want)
+		}
+	}
+
+	// The buckets are cumulative: each counts the observations up to its bound.
+	buckets := map[string]float64{
+		`gga_evaluation_duration_seconds_bucket{le="1e-05"}`:   0,
+		`gga_evaluation_duration_seconds_bucket{le="5e-05"}`:   1,
+		`gga_evaluation_duration_seconds_bucket{le="0.0001"}`:  1,
+		`gga_evaluation_duration_seconds_bucket{le="0.00025"}`: 2,
+		`gga_evaluation_duration_seconds_bucket{le="0.1"}`:     2,
+		`gga_evaluation_duration_seconds_bucket{le="+Inf"}`:    3,
+		`gga_evaluation_duration_seconds_count`:                3,
+		`gga_generation_duration_seconds_bucket{le="+Inf"}`:    1,
+		`gga_generation_duration_seconds_count`:                1,
+	}
+	for name, want := range buckets {
+		if got, ok := samples[name]; !ok || got != want {
+			t.Errorf("%s = %v (present %t), want %v", name, got, ok, want)
+		}
+	}
+
+	sum := samples["gga_evaluation_duration_seconds_sum"]
+	if want := 0.20023; sum < want-1e-9 || sum > want+1e-9 {
+		t.Errorf("evaluation sum %v, want %v", sum, want)
+	}
+
+	previous := 0.0
+	for _, bound := range generationBuckets {
+		count := samples[`gga_generation_duration_seconds_bucket{le="`+formatValue(bound)+`"}`]
+		if count < previous {
+			t.Errorf("generation bucket %g holds %v, fewer than the previous one", bound, count)
+		}
+		previous = count
+	}
+}
+
+func TestCollectorScrapeBeforeFirstGeneration(t *testing.T) {
+	_, samples := scrape(t, NewCollector())
+
+	for _, name := range []string{"gga_generation", "gga_win_rate", "gga_generation_duration_seconds_count"} {
+		if got, ok := samples[name]; !ok || got != 0 {
+			t.Errorf("%s = %v (present %t), want 0", name, got, ok)
+		}
+	}
+}
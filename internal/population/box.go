@@ -5,11 +5,22 @@ import (
 	"image/color"
 	"math"
 	"math/rand"
+	"time"
 
-	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/pipawoz/go_genetic_algorithm/internal/render"
 	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
 )
 
+// DeathCause is what killed a box.
+type DeathCause string
+
+const (
+	// CauseBoundary is leaving the game area.
+	CauseBoundary DeathCause = "boundary"
+	// CauseWall is hitting a wall.
+	CauseWall DeathCause = "wall"
+)
+
 // Box represents an individual in the population.
 type Box struct {
 	IsAlive      bool
@@ -24,6 +35,16 @@ type Box struct {
 	Genes        DNA
 	Dist         float64
 	Frames       int
+	Lineage      Lineage
+	Trajectory   []utils.Vector
+	Novelty      float64
+	Clearance    float64
+	Island       int
+	// Species is the ID of the species the box was bred in, on its island, or 0 without speciation.
+	Species    int
+	DeathCause DeathCause
+	// EvalTime is the wall time spent simulating the box. Only headless runs measure it.
+	EvalTime time.Duration
 }
 
 // NewBox creates a new Box object with the given genes.
@@ -39,7 +60,7 @@ func NewBox(genes *DNA) *Box {
 	return &Box{
 		IsAlive:      true,
 		AliveTime:    0,
-		Position:     utils.Vector{X: 10, Y: float32(utils.GameHeight) / 2},
+		Position:     utils.Vector{X: utils.StartX, Y: utils.StartY},
 		Size:         5,
 		Fitness:      0,
 		Won:          false,
@@ -49,6 +70,8 @@ func NewBox(genes *DNA) *Box {
 		Genes:        DNA{g.Chain},
 		Dist:         0,
 		Frames:       0,
+		Lineage:      Lineage{ID: NextID(), CrossoverPoint: -1},
+		Clearance:    math.MaxFloat64,
 	}
 }
 
@@ -58,7 +81,8 @@ func (box *Box) SetGenes(genes []utils.Vector) {
 }
 
 // CheckCollision checks if the box collides with any walls or goes out of the game boundaries.
-// If a collision is detected, the box's IsAlive flag is set to false.
+// If a collision is detected, the box's IsAlive flag is set to false and the cause is kept in DeathCause.
+// It also keeps the closest the box has been to a wall or a boundary in Clearance.
 // Parameters:
 // - walls: a slice of engine.Obstacle representing the walls in the game.
 // Returns: none.
@@ -67,29 +91,54 @@ func (box *Box) CheckCollision(walls []utils.Obstacle) {
 	if int(box.Position.X)+box.Size > utils.GameWidth ||
 		box.Position.X < -5 || box.Position.Y < -5 || int(box.Position.Y)+
 		box.Size > utils.GameHeight {
-		box.IsAlive = false
+		box.die(CauseBoundary)
 	}
 
 	// Check if the box collides with any wall
 	for _, wall := range walls {
 		if int(box.Position.X) < wall.X+wall.Width && int(box.Position.X)+
 			box.Size > wall.X && int(box.Position.Y) < wall.Y+wall.Height && int(box.Position.Y)+box.Size > wall.Y {
-			box.IsAlive = false
+			box.die(CauseWall)
 		}
 	}
+
+	box.updateClearance(walls)
+}
+
+// die kills the box, keeping the first cause when several collisions happen at once.
+func (box *Box) die(cause DeathCause) {
+	if box.IsAlive {
+		box.IsAlive = false
+		box.DeathCause = cause
+	}
+}
+
+// updateClearance updates Clearance with the current distance to the closest wall or boundary.
+func (box *Box) updateClearance(walls []utils.Obstacle) {
+	if !box.IsAlive && !box.Won {
+		box.Clearance = 0
+		return
+	}
+
+	x, y, size := float64(box.Position.X), float64(box.Position.Y), float64(box.Size)
+	clearance := math.Min(math.Min(x, y), math.Min(utils.GameWidth-x-size, utils.GameHeight-y-size))
+
+	for _, wall := range walls {
+		dx := math.Max(math.Max(float64(wall.X)-(x+size), x-float64(wall.X+wall.Width)), 0)
+		dy := math.Max(math.Max(float64(wall.Y)-(y+size), y-float64(wall.Y+wall.Height)), 0)
+		clearance = math.Min(clearance, math.Hypot(dx, dy))
+	}
+
+	box.Clearance = math.Max(math.Min(box.Clearance, clearance), 0)
 }
 
 // CalculateFitness calculates the fitness of the box based on its position, distance to the goal, and other factors.
 // It updates the Fitness field of the box.
 // Returns: none.
 func (box *Box) CalculateFitness() {
-	goalSize := 40
-	goalX := 1280 - goalSize - 10
-	goalY := (720 / 2) - (goalSize / 2)
-
 	// Calculate the fitness of the box
-	box.Dist = math.Sqrt(math.Pow(float64(box.Position.X-float32(goalX)), 2) +
-		math.Pow(float64(box.Position.Y-float32(goalY)), 2))
+	box.Dist = math.Sqrt(math.Pow(float64(box.Position.X-float32(utils.GoalX)), 2) +
+		math.Pow(float64(box.Position.Y-float32(utils.GoalY)), 2))
 
 	box.Fitness = 1 - (box.Dist / float64(utils.GameWidth))
 
@@ -107,8 +156,8 @@ func (box *Box) CalculateFitness() {
 // Reset resets the state of the Box.
 func (box *Box) Reset() {
 	box.IsAlive = true
-	box.Position.X = 10
-	box.Position.Y = float32(utils.GameHeight) / 2
+	box.Position.X = utils.StartX
+	box.Position.Y = utils.StartY
 	box.Velocity = utils.Vector{X: 0, Y: 0}
 	box.Acceleration = utils.Vector{X: 0, Y: 0}
 	box.Won = false
@@ -117,6 +166,11 @@ func (box *Box) Reset() {
 	box.Size = 5
 	box.Fitness = 0
 	box.Dist = 0
+	box.Trajectory = nil
+	box.Novelty = 0
+	box.Clearance = math.MaxFloat64
+	box.DeathCause = ""
+	box.EvalTime = 0
 }
 
 // Update updates the state of the Box.
@@ -129,10 +183,7 @@ func (box *Box) Update(counter int) {
 	boxRect := image.Rect(int(box.Position.X), int(box.Position.Y),
 		int(box.Position.X)+box.Size, int(box.Position.Y)+box.Size)
 
-	goalSize := 40
-	goalX := 1280 - goalSize - 10
-	goalY := (720 / 2) - (goalSize / 2)
-	winRect := image.Rect(goalX, goalY, goalX+goalSize, goalY+goalSize)
+	winRect := image.Rect(utils.GoalX, utils.GoalY, utils.GoalX+utils.GoalSize, utils.GoalY+utils.GoalSize)
 
 	if boxRect.Overlaps(winRect) && !box.Won {
 		box.Frames = counter
@@ -142,8 +193,9 @@ func (box *Box) Update(counter int) {
 		box.IsAlive = false
 
 		// Replace the remaining genes with no-ops
-		for i := range box.Genes.Chain[box.Frames+1:] {
-			box.Genes.Chain[box.Frames+1+i] = utils.Vector{X: 0, Y: 0}
+		first := min(box.Frames+1, len(box.Genes.Chain))
+		for i := range box.Genes.Chain[first:] {
+			box.Genes.Chain[first+i] = utils.Vector{X: 0, Y: 0}
 		}
 	}
 
@@ -161,67 +213,103 @@ func (box *Box) Update(counter int) {
 
 	box.Traveled += math.Sqrt(math.Pow(float64(box.Velocity.X), 2) +
 		math.Pow(float64(box.Velocity.Y), 2))
+
+	// Sample the trajectory used as behavior descriptor by novelty search
+	if stride := utils.DNASettings.NoveltyTrajectoryStride; stride > 0 && counter%stride == 0 {
+		box.Trajectory = append(box.Trajectory, box.Position)
+	}
 }
 
 // Mutate applies mutation to the Box's genes based on the mutation rate specified in the settings.
+func (box *Box) Mutate() {
+	box.MutateWithRate(utils.DNASettings.MutationRate)
+}
+
+// MutateWithRate applies mutation to the Box's genes with the given mutation rate.
 // If the mutation rate is met, a random gene in the Box's gene chain is selected and its X or Y value is multiplied by 1.01.
 // The mutation quantity is set to 1 by default.
-func (box *Box) Mutate() {
+func (box *Box) MutateWithRate(rate float64) {
 	randomValue := rand.Float64()
-	if randomValue < utils.DNASettings.MutationRate {
+	if randomValue < rate {
 		mutationQuantity := 1
 		for i := 0; i < mutationQuantity; i++ {
-			index := rand.Int() % (len(box.Genes.Chain) - 1)
-			if rand.Int()%10 > 5 {
-				box.Genes.Chain[index].Y *= 1.01
-			} else {
-				box.Genes.Chain[index].X *= 1.01
-			}
+			box.MutateGene(rand.Int()%(len(box.Genes.Chain)-1), rand.Int()%10 > 5)
 		}
 	}
 }
 
+// MutateGene multiplies the Y value of the gene at index by 1.01 when y is set, and its X value otherwise.
+// The mutation is recorded in the Box's lineage.
+func (box *Box) MutateGene(index int, y bool) {
+	box.Lineage.Mutations = append(box.Lineage.Mutations, index)
+	if y {
+		box.Genes.Chain[index].Y *= 1.01
+	} else {
+		box.Genes.Chain[index].X *= 1.01
+	}
+}
+
 // Crossover applies crossover to the Box's genes based on the crossover rate specified in the settings.
-// If the crossover rate is met, the Box's genes are crossed with the partner's genes.
-// The crossover point is randomly selected.
-// The new genes are created by combining the genes of the Box and the partner.
-// Returns: two new Box objects with the new genes.
 func (box *Box) Crossover(partner Box) (Box, Box) {
-	if rand.Float64() < utils.DNASettings.CrossoverRate {
-		newGenes1 := make([]utils.Vector, len(box.Genes.Chain))
-		newGenes2 := make([]utils.Vector, len(box.Genes.Chain))
-
-		middlePoint := rand.Intn(len(box.Genes.Chain)-1) + 1
-
-		for i := range box.Genes.Chain {
-			if i < middlePoint {
-				newGenes1[i] = partner.Genes.Chain[i]
-				newGenes2[i] = box.Genes.Chain[i]
-			} else {
-				newGenes1[i] = box.Genes.Chain[i]
-				newGenes2[i] = partner.Genes.Chain[i]
-			}
-		}
+	return box.CrossoverWithRate(partner, utils.DNASettings.CrossoverRate)
+}
 
-		newBox1, newBox2 := Box{Genes: DNA{Chain: newGenes1}}, Box{Genes: DNA{Chain: newGenes2}}
-		return newBox1, newBox2
+// CrossoverWithRate applies crossover to the Box's genes with the given crossover rate.
+// If the crossover rate is met, the Box's genes are crossed with the partner's genes at a random point.
+// Otherwise the offspring are copies of the Box and the partner.
+// Returns: two new Box objects with the new genes and new IDs.
+func (box *Box) CrossoverWithRate(partner Box, rate float64) (Box, Box) {
+	length := min(len(box.Genes.Chain), len(partner.Genes.Chain))
+	if length > 1 && rand.Float64() < rate {
+		return box.CrossoverAt(partner, rand.Intn(length-1)+1)
 	}
 
-	newBox1, newBox2 := Box{Genes: DNA{Chain: box.Genes.Chain}}, Box{Genes: DNA{Chain: partner.Genes.Chain}}
-	return newBox1, newBox2
+	return box.Offspring(), partner.Offspring()
+}
+
+// CrossoverAt crosses the Box's genes with the partner's genes at the given point.
+// The first offspring takes the genes of the partner before the point and the genes of the Box after it;
+// the second one takes the rest. The offspring belong to the island of the Box.
+// When the chains differ in length, the offspring have the length of the shorter one.
+// Returns: two new Box objects with the new genes and new IDs, whose lineage points to both parents.
+func (box *Box) CrossoverAt(partner Box, middlePoint int) (Box, Box) {
+	length := min(len(box.Genes.Chain), len(partner.Genes.Chain))
+	newGenes1 := make([]utils.Vector, length)
+	newGenes2 := make([]utils.Vector, length)
+
+	for i := 0; i < length; i++ {
+		if i < middlePoint {
+			newGenes1[i] = partner.Genes.Chain[i]
+			newGenes2[i] = box.Genes.Chain[i]
+		} else {
+			newGenes1[i] = box.Genes.Chain[i]
+			newGenes2[i] = partner.Genes.Chain[i]
+		}
+	}
 
+	newBox1, newBox2 := Box{Genes: DNA{Chain: newGenes1}, Island: box.Island}, Box{Genes: DNA{Chain: newGenes2}, Island: box.Island}
+	parents := []uint64{box.Lineage.ID, partner.Lineage.ID}
+	newBox1.Lineage = Lineage{ID: NextID(), Parents: parents, CrossoverPoint: middlePoint}
+	newBox2.Lineage = Lineage{ID: NextID(), Parents: parents, CrossoverPoint: middlePoint}
+	return newBox1, newBox2
 }
 
-// Draw draws the Box on the screen.
-func (box *Box) Draw(screen *ebiten.Image) {
-	// Create an empty image of the size of the individual
-	img := ebiten.NewImage(box.Size, box.Size)
+// Offspring returns a copy of the Box's genes as a new individual of the same island,
+// with a new ID and the Box as its only parent.
+func (box *Box) Offspring() Box {
+	return Box{
+		Genes:   box.Genes.Clone(),
+		Island:  box.Island,
+		Lineage: Lineage{ID: NextID(), Parents: []uint64{box.Lineage.ID}, CrossoverPoint: -1},
+	}
+}
 
-	// Fill the image with a color
-	img.Fill(color.RGBA{255, 0, 0, 255})
+// Draw draws the Box with the renderer.
+func (box *Box) Draw(r render.Renderer) {
+	box.DrawColor(r, color.RGBA{255, 0, 0, 255})
+}
 
-	// Draw the image on the screen at the individual's position
-	opts := &ebiten.DrawImageOptions{}
-	opts.GeoM.Translate(float64(box.Position.X), float64(box.Position.Y))
-	screen.DrawImage(img, opts)
+// DrawColor draws the Box with the renderer in the given color.
+func (box *Box) DrawColor(r render.Renderer, clr color.Color) {
+	r.FillRect(box.Position.X, box.Position.Y, float32(box.Size), float32(box.Size), clr)
 }
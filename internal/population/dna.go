@@ -7,6 +7,9 @@ import (
 	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
 )
 
+// GeneLength is the number of genes of a randomly generated DNA.
+const GeneLength = utils.MaxMoves
+
 // DNA of the individual. It contains the genes of the individual.
 type DNA struct {
 	Chain []utils.Vector
@@ -20,7 +23,7 @@ func (dna *DNA) NewDNA(genes []utils.Vector) *DNA {
 	if genes != nil {
 		dna.Chain = genes
 	} else {
-		for i := 0; i < 1000; i++ {
+		for i := 0; i < GeneLength; i++ {
 			angle := float64(rand.Intn(360)) * math.Pi / 180
 			dna.Chain = append(dna.Chain, utils.Vector{X: float32(math.Cos(angle)),
 				Y: float32(math.Sin(angle))})
@@ -29,3 +32,18 @@ func (dna *DNA) NewDNA(genes []utils.Vector) *DNA {
 
 	return dna
 }
+
+// Clone returns a copy of the DNA that does not share its chain.
+// Boxes overwrite their genes when they win, so seeds and saved genomes must be cloned.
+func (dna DNA) Clone() DNA {
+	chain := make([]utils.Vector, len(dna.Chain))
+	copy(chain, dna.Chain)
+	return DNA{Chain: chain}
+}
+
+// Extend pads the chain with no-op genes until it holds at least length genes.
+func (dna *DNA) Extend(length int) {
+	for len(dna.Chain) < length {
+		dna.Chain = append(dna.Chain, utils.Vector{X: 0, Y: 0})
+	}
+}
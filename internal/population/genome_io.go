@@ -0,0 +1,207 @@
+package population
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// genomeMagic identifies the binary genome format.
+var genomeMagic = [4]byte{'G', 'G', 'A', 'G'}
+
+// genomeVersion is the version written to both genome formats.
+const genomeVersion = 1
+
+// genomeFile is the JSON representation of a set of genomes.
+// Each gene is stored as an [x, y] pair to keep the file compact.
+type genomeFile struct {
+	Version int            `json:"version"`
+	Genomes [][][2]float32 `json:"genomes"`
+}
+
+// SaveGenomes writes the genomes to path.
+// Files ending in .json are written as JSON, any other extension uses the binary format.
+func SaveGenomes(path string, genomes []DNA) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = WriteGenomesJSON(writer, genomes)
+	} else {
+		err = WriteGenomesBinary(writer, genomes)
+	}
+
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// LoadGenomes reads one or many genomes from path.
+// The format is detected from the file contents, not from its extension.
+func LoadGenomes(path string) ([]DNA, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var genomes []DNA
+	if bytes.HasPrefix(data, genomeMagic[:]) {
+		genomes, err = ReadGenomesBinary(bytes.NewReader(data))
+	} else {
+		genomes, err = ReadGenomesJSON(bytes.NewReader(data))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return genomes, nil
+}
+
+// WriteGenomesJSON writes the genomes as JSON.
+func WriteGenomesJSON(w io.Writer, genomes []DNA) error {
+	out := genomeFile{Version: genomeVersion, Genomes: make([][][2]float32, len(genomes))}
+
+	for i, genome := range genomes {
+		out.Genomes[i] = make([][2]float32, len(genome.Chain))
+		for j, gene := range genome.Chain {
+			out.Genomes[i][j] = [2]float32{gene.X, gene.Y}
+		}
+	}
+
+	return json.NewEncoder(w).Encode(out)
+}
+
+// ReadGenomesJSON reads genomes written by WriteGenomesJSON.
+func ReadGenomesJSON(r io.Reader) ([]DNA, error) {
+	var in genomeFile
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, err
+	}
+
+	if in.Version != genomeVersion {
+		return nil, fmt.Errorf("unsupported genome version %d", in.Version)
+	}
+
+	genomes := make([]DNA, len(in.Genomes))
+	for i, chain := range in.Genomes {
+		genomes[i].Chain = make([]utils.Vector, len(chain))
+		for j, gene := range chain {
+			genomes[i].Chain[j] = utils.Vector{X: gene[0], Y: gene[1]}
+		}
+	}
+
+	return genomes, validateGenomes(genomes)
+}
+
+// WriteGenomesBinary writes the genomes in the binary format.
+// The layout is the magic, a version byte, the genome count and, for every genome,
+// its length followed by the X and Y of each gene, all little endian.
+func WriteGenomesBinary(w io.Writer, genomes []DNA) error {
+	if _, err := w.Write(genomeMagic[:]); err != nil {
+		return err
+	}
+
+	header := []any{uint8(genomeVersion), uint32(len(genomes))}
+	for _, value := range header {
+		if err := binary.Write(w, binary.LittleEndian, value); err != nil {
+			return err
+		}
+	}
+
+	for _, genome := range genomes {
+		if err := binary.Write(w, binary.LittleEndian, uint32(len(genome.Chain))); err != nil {
+			return err
+		}
+
+		if err := binary.Write(w, binary.LittleEndian, genome.Chain); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadGenomesBinary reads genomes written by WriteGenomesBinary.
+func ReadGenomesBinary(r io.Reader) ([]DNA, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+
+	if magic != genomeMagic {
+		return nil, errors.New("not a genome file")
+	}
+
+	var version uint8
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, err
+	}
+
+	if version != genomeVersion {
+		return nil, fmt.Errorf("unsupported genome version %d", version)
+	}
+
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+
+	var genomes []DNA
+	for i := uint32(0); i < count; i++ {
+		var length uint32
+		if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+			return nil, err
+		}
+
+		if length > 1<<20 {
+			return nil, fmt.Errorf("genome %d is too long (%d genes)", i, length)
+		}
+
+		chain := make([]utils.Vector, length)
+		if err := binary.Read(r, binary.LittleEndian, chain); err != nil {
+			return nil, err
+		}
+
+		genomes = append(genomes, DNA{Chain: chain})
+	}
+
+	return genomes, validateGenomes(genomes)
+}
+
+// validateGenomes checks that the file held at least one non-empty genome,
+// and no genome longer than the longest move limit.
+func validateGenomes(genomes []DNA) error {
+	if len(genomes) == 0 {
+		return errors.New("no genomes found")
+	}
+
+	for i, genome := range genomes {
+		if len(genome.Chain) == 0 {
+			return fmt.Errorf("genome %d is empty", i)
+		}
+		if len(genome.Chain) > utils.MaxMoves {
+			return fmt.Errorf("genome %d has %d genes, more than %d", i, len(genome.Chain), utils.MaxMoves)
+		}
+	}
+
+	return nil
+}
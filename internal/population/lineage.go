@@ -0,0 +1,23 @@
+package population
+
+import "sync/atomic"
+
+// Lineage records the identity of an individual and how it was bred.
+type Lineage struct {
+	ID         uint64
+	Parents    []uint64
+	Generation int
+	// CrossoverPoint is the gene where the offspring switches from one parent
+	// to the other, or -1 when the genes were copied without crossover.
+	CrossoverPoint int
+	// Mutations are the indexes of the mutated genes.
+	Mutations []int
+}
+
+// lastID is the last ID given to an individual.
+var lastID atomic.Uint64
+
+// NextID Returns a new unique individual ID.
+func NextID() uint64 {
+	return lastID.Add(1)
+}
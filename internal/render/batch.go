@@ -0,0 +1,101 @@
+package render
+
+import (
+	"image/color"
+	"math"
+)
+
+// MaxBatchVertices is the most vertices drawn at once, as the indices are 16-bit.
+const MaxBatchVertices = math.MaxUint16 + 1
+
+// Vertex is a corner of a triangle, with its color premultiplied by its alpha,
+// each channel from 0 to 1.
+type Vertex struct {
+	X, Y       float32
+	R, G, B, A float32
+}
+
+// Surface draws the triangles collected by a Batch, e.g. on an Ebiten image.
+type Surface interface {
+	// Fill fills the whole surface with a color.
+	Fill(clr color.Color)
+	// DrawTriangles draws the triangles of the indices, three per triangle, in one call.
+	DrawTriangles(vertices []Vertex, indices []uint16)
+}
+
+// Batch is a Renderer collecting the rectangles and lines as triangles, which Flush draws
+// on the surface in a single call. The buffers are kept from one flush to the next,
+// so drawing a frame allocates nothing once they have grown to its size.
+// The zero value is ready to use once Begin has set the surface.
+type Batch struct {
+	surface  Surface
+	vertices []Vertex
+	indices  []uint16
+	draws    int
+}
+
+// Begin Starts drawing on the surface. The shapes of the previous surface must have been flushed.
+func (b *Batch) Begin(surface Surface) {
+	b.surface = surface
+}
+
+// Fill Draws the shapes collected so far, then fills the whole surface with a color.
+func (b *Batch) Fill(clr color.Color) {
+	b.Flush()
+	b.surface.Fill(clr)
+}
+
+// FillRect Adds the rectangle at x, y filled with a color.
+func (b *Batch) FillRect(x, y, width, height float32, clr color.Color) {
+	b.quad(x, y, x+width, y, x, y+height, x+width, y+height, clr)
+}
+
+// StrokeLine Adds a line of the given width from x0, y0 to x1, y1, as a rectangle along the line.
+func (b *Batch) StrokeLine(x0, y0, x1, y1, width float32, clr color.Color) {
+	length := float32(math.Hypot(float64(x1-x0), float64(y1-y0)))
+	if length == 0 {
+		return
+	}
+
+	// Half the width, perpendicular to the line.
+	nx := (y0 - y1) / length * width / 2
+	ny := (x1 - x0) / length * width / 2
+	b.quad(x0+nx, y0+ny, x1+nx, y1+ny, x0-nx, y0-ny, x1-nx, y1-ny, clr)
+}
+
+// quad Adds the quadrilateral with the given corners, in the order top left, top right,
+// bottom left and bottom right, as two triangles.
+func (b *Batch) quad(x0, y0, x1, y1, x2, y2, x3, y3 float32, clr color.Color) {
+	if len(b.vertices)+4 > MaxBatchVertices {
+		b.Flush()
+	}
+
+	r, g, bl, a := clr.RGBA()
+	vertex := Vertex{R: float32(r) / 0xffff, G: float32(g) / 0xffff, B: float32(bl) / 0xffff, A: float32(a) / 0xffff}
+
+	first := uint16(len(b.vertices))
+	for _, corner := range [4][2]float32{{x0, y0}, {x1, y1}, {x2, y2}, {x3, y3}} {
+		vertex.X, vertex.Y = corner[0], corner[1]
+		b.vertices = append(b.vertices, vertex)
+	}
+
+	b.indices = append(b.indices, first, first+1, first+2, first+1, first+3, first+2)
+}
+
+// Flush Draws the shapes collected so far on the surface.
+func (b *Batch) Flush() {
+	if len(b.indices) == 0 {
+		return
+	}
+
+	b.surface.DrawTriangles(b.vertices, b.indices)
+	b.draws++
+
+	b.vertices = b.vertices[:0]
+	b.indices = b.indices[:0]
+}
+
+// Draws Returns the number of times the batch has drawn on a surface.
+func (b *Batch) Draws() int {
+	return b.draws
+}
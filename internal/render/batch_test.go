@@ -0,0 +1,146 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"testing"
+)
+
+// recorder is a Surface that records what it is asked to draw.
+type recorder struct {
+	calls    []string
+	vertices []Vertex
+	indices  []uint16
+}
+
+func (r *recorder) Fill(clr color.Color) {
+	r.calls = append(r.calls, "fill")
+}
+
+func (r *recorder) DrawTriangles(vertices []Vertex, indices []uint16) {
+	r.calls = append(r.calls, fmt.Sprintf("triangles %d", len(indices)/3))
+	r.vertices = append(r.vertices[:0], vertices...)
+	r.indices = append(r.indices[:0], indices...)
+}
+
+func TestBatchFillRect(t *testing.T) {
+	var surface recorder
+	var batch Batch
+	batch.Begin(&surface)
+
+	batch.FillRect(10, 20, 5, 5, color.RGBA{255, 0, 0, 255})
+	if len(surface.calls) != 0 {
+		t.Fatalf("drew %v before the flush", surface.calls)
+	}
+
+	batch.Flush()
+	batch.Flush()
+	if len(surface.calls) != 1 || surface.calls[0] != "triangles 2" || batch.Draws() != 1 {
+		t.Fatalf("calls %v, draws %d, want a single draw of 2 triangles", surface.calls, batch.Draws())
+	}
+
+	corners := [][2]float32{{10, 20}, {15, 20}, {10, 25}, {15, 25}}
+	for i, v := range surface.vertices {
+		if v.X != corners[i][0] || v.Y != corners[i][1] || v.R != 1 || v.G != 0 || v.B != 0 || v.A != 1 {
+			t.Errorf("vertex %d = %+v, want red at %v", i, v, corners[i])
+		}
+	}
+}
+
+func TestBatchStrokeLine(t *testing.T) {
+	var surface recorder
+	var batch Batch
+	batch.Begin(&surface)
+
+	batch.StrokeLine(0, 0, 10, 0, 2, color.White)
+	batch.StrokeLine(5, 5, 5, 5, 2, color.White) // empty, nothing to draw
+	batch.Flush()
+
+	if len(surface.vertices) != 4 {
+		t.Fatalf("got %d vertices, want 4", len(surface.vertices))
+	}
+	for _, v := range surface.vertices {
+		if (v.X != 0 && v.X != 10) || (v.Y != 1 && v.Y != -1) {
+			t.Errorf("vertex %+v is not a corner of the 2 pixel wide line", v)
+		}
+	}
+}
+
+func TestBatchFillDrawsPendingShapesFirst(t *testing.T) {
+	var surface recorder
+	var batch Batch
+	batch.Begin(&surface)
+
+	batch.FillRect(0, 0, 1, 1, color.White)
+	batch.Fill(color.Black)
+
+	if fmt.Sprint(surface.calls) != "[triangles 2 fill]" {
+		t.Errorf("calls %v, want the rectangle drawn before the fill", surface.calls)
+	}
+}
+
+func TestBatchSplitsAtIndexLimit(t *testing.T) {
+	var surface recorder
+	var batch Batch
+	batch.Begin(&surface)
+
+	// Four vertices per rectangle: 20000 rectangles need more than 16-bit indices.
+	for i := 0; i < 20000; i++ {
+		batch.FillRect(float32(i%100), float32(i/100), 1, 1, color.White)
+		if len(batch.vertices) > MaxBatchVertices {
+			t.Fatalf("%d vertices collected", len(batch.vertices))
+		}
+	}
+	batch.Flush()
+
+	if batch.Draws() != 2 {
+		t.Errorf("%d draws, want 2", batch.Draws())
+	}
+	for _, index := range surface.indices {
+		if int(index) >= len(surface.vertices) {
+			t.Fatalf("index %d out of %d vertices", index, len(surface.vertices))
+		}
+	}
+}
+
+// counter is a Surface that only counts the draws, standing for a graphics backend.
+type counter struct {
+	draws int
+}
+
+func (c *counter) Fill(clr color.Color) {}
+
+func (c *counter) DrawTriangles(vertices []Vertex, indices []uint16) {
+	c.draws++
+}
+
+// BenchmarkBatch Builds frames of the window: the background, the goal, the walls of level 5
+// and a population of boxes, drawn in one call. The surface only counts the calls, so this
+// measures the cost of collecting the shapes, not of drawing them; the gain over the former
+// per-shape drawing is measured by bench --draw --per-shape in a window.
+func BenchmarkBatch(b *testing.B) {
+	walls := [][4]float32{{200, 300, 20, 420}, {500, 0, 20, 350}, {800, 300, 20, 420}, {1100, 0, 20, 350}}
+
+	for _, boxes := range []int{1000, 10000} {
+		b.Run(fmt.Sprintf("boxes=%d", boxes), func(b *testing.B) {
+			var surface counter
+			var batch Batch
+			batch.Begin(&surface)
+
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				batch.Fill(color.Black)
+				batch.FillRect(1150, 300, 50, 50, color.RGBA{0, 255, 0, 255})
+				for _, wall := range walls {
+					batch.FillRect(wall[0], wall[1], wall[2], wall[3], color.RGBA{255, 255, 255, 255})
+				}
+				for i := 0; i < boxes; i++ {
+					batch.FillRect(float32(i%1200), float32(i*7%720), 5, 5, color.RGBA{255, 0, 0, 255})
+				}
+				batch.Flush()
+			}
+
+			b.ReportMetric(float64(surface.draws)/float64(b.N), "draws/frame")
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Image draws on an image.RGBA in pure Go, so pictures can be produced without a window
+// or a graphics context. Colors with an alpha below 255 are blended over the image.
+type Image struct {
+	RGBA *image.RGBA
+}
+
+// NewImage Creates a renderer drawing on a new transparent image of the given size.
+func NewImage(width, height int) *Image {
+	return &Image{RGBA: image.NewRGBA(image.Rect(0, 0, width, height))}
+}
+
+// Fill Replaces every pixel of the image with a color.
+func (m *Image) Fill(clr color.Color) {
+	draw.Draw(m.RGBA, m.RGBA.Bounds(), image.NewUniform(clr), image.Point{}, draw.Src)
+}
+
+// FillRect Fills the rectangle at x, y with a color. Ebiten draws the rectangles of the
+// window the same way, with the position rounded down and the size truncated.
+func (m *Image) FillRect(x, y, width, height float32, clr color.Color) {
+	left, top := int(math.Floor(float64(x))), int(math.Floor(float64(y)))
+	rect := image.Rect(left, top, left+int(width), top+int(height))
+	draw.Draw(m.RGBA, rect.Intersect(m.RGBA.Bounds()), image.NewUniform(clr), image.Point{}, draw.Over)
+}
+
+// StrokeLine Draws a line of the given width from x0, y0 to x1, y1, as squares of the width
+// at every pixel along the line.
+func (m *Image) StrokeLine(x0, y0, x1, y1, width float32, clr color.Color) {
+	size := max(float32(math.Round(float64(width))), 1)
+	steps := int(math.Ceil(math.Max(math.Abs(float64(x1-x0)), math.Abs(float64(y1-y0)))))
+
+	for i := 0; i <= steps; i++ {
+		t := float32(1)
+		if steps > 0 {
+			t = float32(i) / float32(steps)
+		}
+
+		x := x0 + (x1-x0)*t
+		y := y0 + (y1-y0)*t
+		m.FillRect(x-size/2+0.5, y-size/2+0.5, size, size, clr)
+	}
+}
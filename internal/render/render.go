@@ -0,0 +1,16 @@
+// Package render abstracts the surface the simulation is drawn on: the walls, the goal, the boxes
+// and their trails. The engine draws the window with an Ebiten backend, and Image draws on an
+// image.RGBA in pure Go, which needs no window or graphics context.
+package render
+
+import "image/color"
+
+// Renderer draws shapes on a surface.
+type Renderer interface {
+	// Fill fills the whole surface with a color.
+	Fill(clr color.Color)
+	// FillRect fills the rectangle at x, y with a color.
+	FillRect(x, y, width, height float32, clr color.Color)
+	// StrokeLine draws a line of the given width from x0, y0 to x1, y1.
+	StrokeLine(x0, y0, x1, y1, width float32, clr color.Color)
+}
@@ -0,0 +1,179 @@
+package sweep
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Aggregate is the outcome of a configuration over all its seeds.
+type Aggregate struct {
+	Config Configuration `json:"config"`
+	Runs   int           `json:"runs"`
+	Failed int           `json:"failed"`
+	// Solved is the number of runs in which a box reached the goal.
+	Solved int `json:"solved"`
+	// AvgFirstWin is the mean first winning generation of the solved runs.
+	AvgFirstWin    float64 `json:"avgFirstWin"`
+	AvgBestFitness float64 `json:"avgBestFitness"`
+	AvgWinRate     float64 `json:"avgWinRate"`
+}
+
+// Summarize Groups the results by configuration, from the best mean final fitness to the worst.
+// Failed runs are counted but left out of the means.
+func Summarize(results []Result) []Aggregate {
+	index := map[string]int{}
+	var aggregates []Aggregate
+
+	for _, result := range results {
+		key := result.Config.String()
+		i, ok := index[key]
+		if !ok {
+			i = len(aggregates)
+			index[key] = i
+			aggregates = append(aggregates, Aggregate{Config: result.Config})
+		}
+
+		aggregate := &aggregates[i]
+		aggregate.Runs++
+		if result.Err != nil {
+			aggregate.Failed++
+			continue
+		}
+
+		if result.FirstWin > 0 {
+			aggregate.Solved++
+			aggregate.AvgFirstWin += float64(result.FirstWin)
+		}
+		aggregate.AvgBestFitness += result.BestFitness
+		aggregate.AvgWinRate += result.WinRate
+	}
+
+	for i := range aggregates {
+		aggregate := &aggregates[i]
+		if completed := aggregate.Runs - aggregate.Failed; completed > 0 {
+			aggregate.AvgBestFitness /= float64(completed)
+			aggregate.AvgWinRate /= float64(completed)
+		}
+		if aggregate.Solved > 0 {
+			aggregate.AvgFirstWin /= float64(aggregate.Solved)
+		}
+	}
+
+	sort.SliceStable(aggregates, func(a, b int) bool {
+		return aggregates[a].AvgBestFitness > aggregates[b].AvgBestFitness
+	})
+
+	return aggregates
+}
+
+// WriteCSV Writes a row per run, with a column per parameter followed by the outcome.
+// A run that never won has an empty first_win.
+func WriteCSV(w io.Writer, keys []string, results []Result) error {
+	writer := csv.NewWriter(w)
+
+	header := append(append([]string(nil), keys...), "seed", "generations", "first_win", "best_fitness", "win_rate", "seconds", "error")
+	writer.Write(header)
+
+	for _, result := range results {
+		row := make([]string, 0, len(header))
+		for _, setting := range result.Config {
+			row = append(row, setting.Value)
+		}
+
+		firstWin, errText := "", ""
+		if result.FirstWin > 0 {
+			firstWin = strconv.Itoa(result.FirstWin)
+		}
+		if result.Err != nil {
+			errText = result.Err.Error()
+		}
+
+		row = append(row,
+			strconv.FormatInt(result.Seed, 10),
+			strconv.Itoa(result.Generations),
+			firstWin,
+			strconv.FormatFloat(result.BestFitness, 'f', 6, 64),
+			strconv.FormatFloat(result.WinRate, 'f', 4, 64),
+			strconv.FormatFloat(result.Duration.Seconds(), 'f', 2, 64),
+			errText,
+		)
+		writer.Write(row)
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON Writes the runs and the summary of every configuration as JSON.
+func WriteJSON(w io.Writer, results []Result) error {
+	type run struct {
+		Config      map[string]string `json:"config"`
+		Seed        int64             `json:"seed"`
+		Generations int               `json:"generations"`
+		FirstWin    int               `json:"firstWin,omitempty"`
+		BestFitness float64           `json:"bestFitness"`
+		WinRate     float64           `json:"winRate"`
+		Seconds     float64           `json:"seconds"`
+		Error       string            `json:"error,omitempty"`
+	}
+
+	runs := make([]run, len(results))
+	for i, result := range results {
+		config := map[string]string{}
+		for _, setting := range result.Config {
+			config[setting.Key] = setting.Value
+		}
+
+		runs[i] = run{
+			Config:      config,
+			Seed:        result.Seed,
+			Generations: result.Generations,
+			FirstWin:    result.FirstWin,
+			BestFitness: result.BestFitness,
+			WinRate:     result.WinRate,
+			Seconds:     result.Duration.Seconds(),
+		}
+		if result.Err != nil {
+			runs[i].Error = result.Err.Error()
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Runs    []run       `json:"runs"`
+		Summary []Aggregate `json:"summary"`
+	}{runs, Summarize(results)})
+}
+
+// Save Writes the results to path, as JSON when it ends in .json and as CSV otherwise.
+func Save(path string, keys []string, results []Result) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = WriteJSON(writer, results)
+	} else {
+		err = WriteCSV(writer, keys, results)
+	}
+
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
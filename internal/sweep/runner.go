@@ -0,0 +1,141 @@
+package sweep
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/genetics"
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// outputSettings are the files written and the servers started during a run. They are disabled
+// in the runs of a sweep, which would otherwise overwrite each other's files and ports.
+var outputSettings = []string{"outputFile", "bestGenomeFile", "heatmapFile", "genealogyFile", "paretoFile", "paramLogFile",
+	"recordFile", "snapshotFile", "dashboardAddr", "metricsAddr"}
+
+// Run is a configuration to run with a seed.
+type Run struct {
+	Config Configuration
+	Seed   int64
+}
+
+// Result is the outcome of a run.
+type Result struct {
+	Run
+	genetics.RunSummary
+	Duration time.Duration
+	Err      error
+}
+
+// Runner runs the configurations in child processes of the headless command.
+// The settings are global to a process, so this is what lets runs go in parallel.
+type Runner struct {
+	// Executable is the program started for every run.
+	Executable string
+	// Args are given to every run before the configuration, e.g. --config and the flags of the sweep command.
+	Args []string
+	// Parallel is the number of runs at once.
+	Parallel int
+}
+
+// Runs Returns every configuration paired with every seed.
+func Runs(configs []Configuration, seeds []int64) []Run {
+	var runs []Run
+	for _, config := range configs {
+		for _, seed := range seeds {
+			runs = append(runs, Run{config, seed})
+		}
+	}
+
+	return runs
+}
+
+// Run Runs every run and returns the results in the same order.
+// progress, if not nil, is called after every run from a single goroutine at a time.
+func (r *Runner) Run(runs []Run, progress func(done int, result Result)) ([]Result, error) {
+	dir, err := os.MkdirTemp("", "gga-sweep-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	results := make([]Result, len(runs))
+	jobs := make(chan int)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	done := 0
+
+	for worker := 0; worker < max(r.Parallel, 1); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := r.run(runs[i], filepath.Join(dir, fmt.Sprintf("run_%d.json", i)))
+
+				mu.Lock()
+				results[i] = result
+				done++
+				if progress != nil {
+					progress(done, result)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range runs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
+
+// run Runs a configuration with a seed and reads the summary written by the headless command.
+func (r *Runner) run(run Run, summaryFile string) Result {
+	args := append([]string{"headless"}, r.Args...)
+	for _, key := range outputSettings {
+		args = append(args, "--"+utils.FlagName(key)+"=")
+	}
+	for _, setting := range run.Config {
+		args = append(args, "--"+utils.FlagName(setting.Key)+"="+setting.Value)
+	}
+	// The output of the runs is discarded, so they only log their problems.
+	args = append(args, "--seed="+strconv.FormatInt(run.Seed, 10), "--summary-file="+summaryFile, "--quiet")
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(r.Executable, args...)
+	cmd.Stderr = &stderr
+
+	result := Result{Run: run}
+	start := time.Now()
+	err := cmd.Run()
+	result.Duration = time.Since(start)
+
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			err = errors.New(message)
+		}
+		result.Err = err
+		return result
+	}
+
+	data, err := os.ReadFile(summaryFile)
+	if err == nil {
+		err = json.Unmarshal(data, &result.RunSummary)
+	}
+	result.Err = err
+
+	return result
+}
@@ -0,0 +1,240 @@
+package sweep
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pipawoz/go_genetic_algorithm/internal/utils"
+)
+
+// Search modes of a sweep.
+const (
+	// ModeGrid runs every combination of the parameter values.
+	ModeGrid = "grid"
+	// ModeRandom runs samples configurations with values drawn at random.
+	ModeRandom = "random"
+)
+
+// Spec describes the configurations of a sweep.
+type Spec struct {
+	Mode    string  `json:"mode"`
+	Samples int     `json:"samples"`
+	Seeds   []int64 `json:"seeds"`
+	// Parameters maps a setting to a list of values, or to a {"min", "max"} range in random mode.
+	Parameters map[string]json.RawMessage `json:"parameters"`
+
+	params []Parameter
+}
+
+// Parameter is a setting varied by the sweep.
+type Parameter struct {
+	Key    string
+	Values []string
+	// Min and Max bound the values drawn in random mode when Values is empty.
+	Min, Max float64
+	kind     reflect.Kind
+}
+
+// Setting is the value of a parameter in a configuration.
+type Setting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Configuration is a set of parameter values to run.
+type Configuration []Setting
+
+// String Returns the configuration as key=value pairs.
+func (c Configuration) String() string {
+	pairs := make([]string, len(c))
+	for i, setting := range c {
+		pairs[i] = setting.Key + "=" + setting.Value
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// paramRange is a range of values drawn in random mode.
+type paramRange struct {
+	Min *float64 `json:"min"`
+	Max *float64 `json:"max"`
+}
+
+// LoadSpec Reads and checks a sweep spec. The parameters must be settings of
+// genetic_settings.json or settings.json, and their values must suit the setting type.
+// All the problems are reported at once in a utils.ValidationErrors.
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &Spec{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(spec); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if spec.Mode == "" {
+		spec.Mode = ModeGrid
+	}
+
+	if len(spec.Seeds) == 0 {
+		spec.Seeds = []int64{1}
+	}
+
+	var errs utils.ValidationErrors
+	switch spec.Mode {
+	case ModeGrid:
+	case ModeRandom:
+		if spec.Samples < 1 {
+			errs = append(errs, utils.ValidationError{File: path, Field: "samples", Message: fmt.Sprintf("must be at least 1 in random mode, got %d", spec.Samples)})
+		}
+	default:
+		errs = append(errs, utils.ValidationError{File: path, Field: "mode", Message: fmt.Sprintf("must be one of %q, got %q", []string{ModeGrid, ModeRandom}, spec.Mode)})
+	}
+
+	if len(spec.Parameters) == 0 {
+		errs = append(errs, utils.ValidationError{File: path, Field: "parameters", Message: "must list at least one setting"})
+	}
+
+	keys := make([]string, 0, len(spec.Parameters))
+	for key := range spec.Parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		param, paramErrs := parseParameter(path, spec.Mode, key, spec.Parameters[key])
+		errs = append(errs, paramErrs...)
+		spec.params = append(spec.params, param)
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+// parseParameter Reads the values of a parameter and checks them against the setting.
+func parseParameter(path, mode, key string, raw json.RawMessage) (Parameter, utils.ValidationErrors) {
+	field := "parameters." + key
+	param := Parameter{Key: key}
+
+	kind, ok := utils.LookupSetting(key, &utils.DNASettings, &utils.Settings)
+	if !ok {
+		return param, utils.ValidationErrors{{File: path, Field: field, Message: "unknown setting"}}
+	}
+	param.kind = kind
+
+	var values []any
+	if err := json.Unmarshal(raw, &values); err == nil {
+		if len(values) == 0 {
+			return param, utils.ValidationErrors{{File: path, Field: field, Message: "must list at least one value"}}
+		}
+
+		var errs utils.ValidationErrors
+		for i, value := range values {
+			text := formatValue(value)
+			if err := utils.ParseSetting(key, text, &utils.DNASettings, &utils.Settings); err != nil {
+				errs = append(errs, utils.ValidationError{File: path, Field: fmt.Sprintf("%s[%d]", field, i), Message: fmt.Sprintf("expected a %s, got %s", kind, text)})
+			}
+			param.Values = append(param.Values, text)
+		}
+
+		return param, errs
+	}
+
+	var bounds paramRange
+	if err := json.Unmarshal(raw, &bounds); err != nil || bounds.Min == nil || bounds.Max == nil {
+		return param, utils.ValidationErrors{{File: path, Field: field, Message: `must be a list of values or a {"min", "max"} range`}}
+	}
+
+	switch {
+	case mode != ModeRandom:
+		return param, utils.ValidationErrors{{File: path, Field: field, Message: "ranges are only allowed in random mode"}}
+	case kind != reflect.Int && kind != reflect.Int64 && kind != reflect.Float64:
+		return param, utils.ValidationErrors{{File: path, Field: field, Message: fmt.Sprintf("ranges need a number setting, got a %s", kind)}}
+	case *bounds.Min > *bounds.Max:
+		return param, utils.ValidationErrors{{File: path, Field: field, Message: fmt.Sprintf("min %g is greater than max %g", *bounds.Min, *bounds.Max)}}
+	}
+
+	param.Min, param.Max = *bounds.Min, *bounds.Max
+	return param, nil
+}
+
+// formatValue Converts a JSON value to the text given to the setting flag.
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Keys Returns the keys of the parameters, in the order of the configurations.
+func (s *Spec) Keys() []string {
+	keys := make([]string, len(s.params))
+	for i, param := range s.params {
+		keys[i] = param.Key
+	}
+
+	return keys
+}
+
+// Configurations Returns the configurations to run: every combination of the values in grid mode,
+// or Samples random draws in random mode.
+func (s *Spec) Configurations(rng *rand.Rand) []Configuration {
+	if s.Mode == ModeRandom {
+		configs := make([]Configuration, s.Samples)
+		for i := range configs {
+			for _, param := range s.params {
+				configs[i] = append(configs[i], Setting{param.Key, param.draw(rng)})
+			}
+		}
+		return configs
+	}
+
+	configs := []Configuration{{}}
+	for _, param := range s.params {
+		var next []Configuration
+		for _, config := range configs {
+			for _, value := range param.Values {
+				combined := append(append(Configuration(nil), config...), Setting{param.Key, value})
+				next = append(next, combined)
+			}
+		}
+		configs = next
+	}
+
+	return configs
+}
+
+// draw Returns a random value of the parameter.
+// Ranges of integer settings include both ends; float values are rounded to 4 decimals.
+func (p Parameter) draw(rng *rand.Rand) string {
+	if len(p.Values) > 0 {
+		return p.Values[rng.Intn(len(p.Values))]
+	}
+
+	if p.kind == reflect.Int || p.kind == reflect.Int64 {
+		low, high := int64(math.Ceil(p.Min)), int64(math.Floor(p.Max))
+		return strconv.FormatInt(low+rng.Int63n(max(high-low+1, 1)), 10)
+	}
+
+	value := p.Min + rng.Float64()*(p.Max-p.Min)
+	return strconv.FormatFloat(math.Round(value*1e4)/1e4, 'f', -1, 64)
+}
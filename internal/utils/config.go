@@ -4,3 +4,88 @@ const (
 	GameWidth  = 1280
 	GameHeight = 720
 )
+
+// Geometry of the start position and the goal area.
+const (
+	StartX   = 10
+	StartY   = GameHeight / 2
+	GoalSize = 40
+	GoalX    = GameWidth - GoalSize - 10
+	GoalY    = GameHeight/2 - GoalSize/2
+)
+
+// MaxMoves is the number of genes of a DNA, one move per gene. The move limit of a level
+// stays below it, so a box reaching the goal on the last frame still has genes left to clear.
+const MaxMoves = 1000
+
+// Fitness modes selected with the fitnessMode setting.
+const (
+	// FitnessDistance scores the boxes by their distance to the goal.
+	FitnessDistance = "distance"
+	// FitnessNovelty scores the boxes by how different their behavior is.
+	FitnessNovelty = "novelty"
+	// FitnessHybrid mixes both scores with the noveltyWeight setting.
+	FitnessHybrid = "hybrid"
+)
+
+// Algorithms selected with the algorithm setting.
+const (
+	// AlgorithmGA is the single-objective genetic algorithm with roulette selection.
+	AlgorithmGA = "ga"
+	// AlgorithmNSGA2 is the multi-objective NSGA-II algorithm.
+	AlgorithmNSGA2 = "nsga2"
+	// AlgorithmSpecies is the genetic algorithm with speciation and fitness sharing.
+	AlgorithmSpecies = "species"
+)
+
+// Distances used to group individuals into species, selected with the speciesMetric setting.
+const (
+	// SpeciesGenome compares the genes of two individuals.
+	SpeciesGenome = "genome"
+	// SpeciesBehavior compares the trajectories of two individuals.
+	SpeciesBehavior = "behavior"
+)
+
+// Log formats selected with the logFormat setting.
+const (
+	// LogText writes every record as a line of key=value pairs.
+	LogText = "text"
+	// LogJSON writes every record as a JSON object.
+	LogJSON = "json"
+)
+
+// Log levels selected with the logLevel setting, from the most verbose.
+const (
+	// LogDebug adds a record for every box that won or died.
+	LogDebug = "debug"
+	// LogInfo logs a record per generation and the progress of the run.
+	LogInfo = "info"
+	// LogWarn logs the problems that do not stop the run.
+	LogWarn = "warn"
+	// LogError logs only the errors.
+	LogError = "error"
+)
+
+// Trail modes selected with the trailMode setting and cycled with the T key.
+const (
+	// TrailSolid paints every box in red, or in the color of its island.
+	TrailSolid = "solid"
+	// TrailFade makes the trails fade out, multiplying their alpha by trailFade every frame.
+	TrailFade = "fade"
+	// TrailRank colors every box by its rank in distance to the goal, from green to red.
+	TrailRank = "rank"
+	// TrailLineage colors every box by its species or, without speciation, by its first parent.
+	TrailLineage = "lineage"
+	// TrailBest leaves trails only behind the trailBest boxes closest to the goal.
+	TrailBest = "best"
+)
+
+// Migration topologies selected with the migrationTopology setting.
+const (
+	// TopologyRing sends the migrants of every island to the next one.
+	TopologyRing = "ring"
+	// TopologyFull sends the migrants of every island to all the others.
+	TopologyFull = "full"
+	// TopologyRandom sends the migrants of every island to another one picked at random.
+	TopologyRandom = "random"
+)
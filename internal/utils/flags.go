@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix is the prefix of the environment variables that override settings.
+const EnvPrefix = "GGA_"
+
+// Overrides holds the setting values given on the command line.
+// The values are recorded while parsing and applied after the JSON files are loaded,
+// so flags always take precedence over the configuration files and the environment.
+type Overrides struct {
+	values []*settingValue
+}
+
+// settingValue is a flag.Value bound to a settings field.
+type settingValue struct {
+	name  string
+	field reflect.Value
+	raw   string
+	set   bool
+}
+
+// String returns the value given on the command line.
+func (v *settingValue) String() string {
+	if v == nil {
+		return ""
+	}
+
+	return v.raw
+}
+
+// Set records the value after checking that it parses for the field type.
+func (v *settingValue) Set(raw string) error {
+	if err := setField(reflect.New(v.field.Type()).Elem(), raw); err != nil {
+		return err
+	}
+
+	v.raw = raw
+	v.set = true
+	return nil
+}
+
+// IsBoolFlag allows boolean settings to be given without a value.
+func (v *settingValue) IsBoolFlag() bool {
+	return v.field.Kind() == reflect.Bool
+}
+
+// BindSettingsFlags registers a flag for every field of the given settings structs.
+// The flag name is the JSON key in kebab case, e.g. populationSize becomes -population-size.
+// Fields that are not strings, booleans or numbers are skipped.
+func BindSettingsFlags(fs *flag.FlagSet, targets ...any) *Overrides {
+	overrides := &Overrides{}
+
+	forEachSetting(targets, func(key string, field reflect.Value) {
+		value := &settingValue{name: FlagName(key), field: field}
+		fs.Var(value, FlagName(key), fmt.Sprintf("override the %s `%s` setting", key, field.Kind()))
+		overrides.values = append(overrides.values, value)
+	})
+
+	return overrides
+}
+
+// Apply sets the fields of the flags given on the command line.
+func (o *Overrides) Apply() error {
+	for _, value := range o.values {
+		if !value.set {
+			continue
+		}
+
+		if err := setField(value.field, value.raw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Args Returns the flags given on the command line, so they can be passed on to another process.
+func (o *Overrides) Args() []string {
+	var args []string
+	for _, value := range o.values {
+		if value.set {
+			args = append(args, "--"+value.name+"="+value.raw)
+		}
+	}
+
+	return args
+}
+
+// ApplyEnv overrides the fields of the given settings structs with environment variables.
+// The variable name is the JSON key in upper snake case with the GGA_ prefix,
+// e.g. populationSize is read from GGA_POPULATION_SIZE.
+func ApplyEnv(targets ...any) error {
+	var err error
+
+	forEachSetting(targets, func(key string, field reflect.Value) {
+		raw, ok := os.LookupEnv(EnvName(key))
+		if !ok || err != nil {
+			return
+		}
+
+		if setErr := setField(field, raw); setErr != nil {
+			err = fmt.Errorf("%s: %w", EnvName(key), setErr)
+		}
+	})
+
+	return err
+}
+
+// EnvName returns the environment variable that overrides the given JSON key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(FlagName(key), "-", "_"))
+}
+
+// LookupSetting returns the kind of the scalar setting with the given JSON key in the targets.
+func LookupSetting(key string, targets ...any) (reflect.Kind, bool) {
+	kind, found := reflect.Invalid, false
+
+	forEachSetting(targets, func(name string, field reflect.Value) {
+		if name == key && !found {
+			kind, found = field.Kind(), true
+		}
+	})
+
+	return kind, found
+}
+
+// ParseSetting checks that raw is a valid value for the setting with the given JSON key in the targets.
+func ParseSetting(key, raw string, targets ...any) error {
+	var err error
+	found := false
+
+	forEachSetting(targets, func(name string, field reflect.Value) {
+		if name == key && !found {
+			found = true
+			err = setField(reflect.New(field.Type()).Elem(), raw)
+		}
+	})
+
+	if !found {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+
+	return err
+}
+
+// forEachSetting calls fn with the JSON key and value of every scalar field of the targets.
+func forEachSetting(targets []any, fn func(key string, field reflect.Value)) {
+	for _, target := range targets {
+		value := reflect.ValueOf(target).Elem()
+
+		for i := 0; i < value.NumField(); i++ {
+			key := strings.Split(value.Type().Field(i).Tag.Get("json"), ",")[0]
+			if key == "" || key == "-" {
+				continue
+			}
+
+			switch value.Field(i).Kind() {
+			case reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64, reflect.String:
+				fn(key, value.Field(i))
+			}
+		}
+	}
+}
+
+// setField parses raw according to the field type and stores it.
+func setField(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	case reflect.String:
+		field.SetString(raw)
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Kind())
+	}
+
+	return nil
+}
+
+// FlagName returns the flag name of a JSON key: the camel case key in kebab case.
+func FlagName(key string) string {
+	var name strings.Builder
+
+	for i, r := range key {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				name.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		name.WriteRune(r)
+	}
+
+	return name.String()
+}
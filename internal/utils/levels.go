@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Level represents a level loaded from a levels file.
+type Level struct {
+	Level     int        `json:"level"`
+	MoveLimit int        `json:"moveLimit"`
+	Walls     []Obstacle `json:"walls"`
+}
+
+// LevelsFile represents the contents of a levels file.
+type LevelsFile struct {
+	Levels []Level `json:"levels"`
+}
+
+// CustomLevels holds the levels loaded from the levels file, by level number.
+// They take precedence over the built-in levels with the same number.
+var CustomLevels = map[int]Level{}
+
+// LoadLevels loads the levels defined in the given file into CustomLevels.
+func LoadLevels(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var file LevelsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, level := range file.Levels {
+		CustomLevels[level.Level] = level
+	}
+
+	return nil
+}
+
+// BuiltinLevels is the number of levels defined in LevelLayout.
+const BuiltinLevels = 5
+
+// LevelLayout returns the move limit and walls of the given level without selecting it.
+// Levels loaded from the levels file take precedence over the built-in ones.
+func LevelLayout(currentLevel int) (int, []Obstacle) {
+	if level, ok := CustomLevels[currentLevel]; ok {
+		return level.MoveLimit, level.Walls
+	}
+
+	var moveLimit int
+	var walls []Obstacle
+
+	switch currentLevel {
+	case 1:
+		// Level 1
+		moveLimit = 350
+	case 2:
+		// Level 2
+		moveLimit = 400
+		walls = []Obstacle{
+			{X: 500, Y: 150, Width: 20, Height: 420},
+		}
+	case 3:
+		// Level 3
+		moveLimit = 500
+		walls = []Obstacle{
+			{X: 350, Y: 200, Width: 20, Height: 320},
+			{X: 750, Y: 200, Width: 20, Height: 320},
+			{X: 550, Y: 0, Width: 20, Height: 200},
+			{X: 550, Y: 520, Width: 20, Height: 200},
+		}
+	case 4:
+		// Level 4
+		moveLimit = 600
+		walls = []Obstacle{
+			{X: 300, Y: 0, Width: 20, Height: 400},
+			{X: 500, Y: 400, Width: 20, Height: 320},
+			{X: 730, Y: 0, Width: 20, Height: 310},
+			{X: 730, Y: 420, Width: 20, Height: 300},
+			{X: 750, Y: 290, Width: 300, Height: 20},
+			{X: 750, Y: 420, Width: 300, Height: 20},
+		}
+	case 5:
+		// Level 5
+		moveLimit = 700
+		walls = []Obstacle{
+			{X: 200, Y: 300, Width: 20, Height: 420},
+			{X: 500, Y: 0, Width: 20, Height: 350},
+			{X: 800, Y: 300, Width: 20, Height: 420},
+			{X: 1100, Y: 0, Width: 20, Height: 350},
+		}
+	default:
+		// Default Level
+		moveLimit = 350
+	}
+
+	return moveLimit, walls
+}
+
+// AvailableLevels returns the numbers of the built-in and custom levels, sorted.
+func AvailableLevels() []int {
+	var levels []int
+	for level := 1; level <= BuiltinLevels; level++ {
+		levels = append(levels, level)
+	}
+
+	for level := range CustomLevels {
+		if level > BuiltinLevels {
+			levels = append(levels, level)
+		}
+	}
+
+	sort.Ints(levels)
+	return levels
+}
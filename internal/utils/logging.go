@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"io"
+	"log/slog"
+)
+
+// NewLogger creates the logger set by the logFormat, logLevel and quiet settings.
+// Quiet mode keeps only the warnings and the errors, whatever the level.
+func NewLogger(w io.Writer, s GameSettings) *slog.Logger {
+	var level slog.Level
+	switch s.LogLevel {
+	case LogDebug:
+		level = slog.LevelDebug
+	case LogWarn:
+		level = slog.LevelWarn
+	case LogError:
+		level = slog.LevelError
+	default:
+		level = slog.LevelInfo
+	}
+
+	if s.Quiet {
+		level = max(level, slog.LevelWarn)
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	if s.LogFormat == LogJSON {
+		return slog.New(slog.NewJSONHandler(w, options))
+	}
+
+	return slog.New(slog.NewTextHandler(w, options))
+}
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 )
 
@@ -14,74 +16,131 @@ type Vector struct {
 
 // GameSettings represents the settings for the game.
 type GameSettings struct {
-	PrintTrace   bool   `json:"printTrace"`
-	CurrentLevel int    `json:"currentLevel"`
-	OutputFile   string `json:"outputFile"`
-	SimulateOnly bool   `json:"simulateOnly"`
+	PrintTrace       bool              `json:"printTrace"`
+	CurrentLevel     int               `json:"currentLevel"`
+	OutputFile       string            `json:"outputFile"`
+	SimulateOnly     bool              `json:"simulateOnly"`
+	BestGenomeFile   string            `json:"bestGenomeFile"`
+	SeedGenomeFile   string            `json:"seedGenomeFile"`
+	LevelsFile       string            `json:"levelsFile"`
+	ParamLogFile     string            `json:"paramLogFile"`
+	HeatmapFile      string            `json:"heatmapFile"`
+	GenealogyFile    string            `json:"genealogyFile"`
+	ParetoFile       string            `json:"paretoFile"`
+	Curriculum       []CurriculumStage `json:"curriculum"`
+	LogFormat        string            `json:"logFormat"`
+	LogLevel         string            `json:"logLevel"`
+	Quiet            bool              `json:"quiet"`
+	DashboardAddr    string            `json:"dashboardAddr"`
+	MetricsAddr      string            `json:"metricsAddr"`
+	RecordFile       string            `json:"recordFile"`
+	RecordGeneration int               `json:"recordGeneration"`
+	RecordFrameStep  int               `json:"recordFrameStep"`
+	SnapshotFile     string            `json:"snapshotFile"`
+	SnapshotInterval int               `json:"snapshotInterval"`
+	SnapshotBest     int               `json:"snapshotBest"`
+	TrailMode        string            `json:"trailMode"`
+	TrailFade        float64           `json:"trailFade"`
+	TrailBest        int               `json:"trailBest"`
+	GhostTrail       bool              `json:"ghostTrail"`
+}
+
+// CurriculumStage represents a stage of the curriculum: the level to evolve on,
+// the fraction of boxes that must win to move on and the most generations to spend on it.
+type CurriculumStage struct {
+	Level          int     `json:"level"`
+	WinRate        float64 `json:"winRate"`
+	MaxGenerations int     `json:"maxGenerations"`
 }
 
 // GeneticSettings represents the settings for the genetic algorithm.
 type GeneticSettings struct {
-	Iterations     int     `json:"iterations"`
-	MaxGenerations int     `json:"maxGenerations"`
+	Iterations              int              `json:"iterations"`
+	MaxGenerations          int              `json:"maxGenerations"`
+	PopulationSize          int              `json:"populationSize"`
+	MutationRate            float64          `json:"mutationRate"`
+	CrossoverRate           float64          `json:"crossoverRate"`
+	FitnessMode             string           `json:"fitnessMode"`
+	NoveltyNeighbors        int              `json:"noveltyNeighbors"`
+	NoveltyWeight           float64          `json:"noveltyWeight"`
+	NoveltyTrajectoryStride int              `json:"noveltyTrajectoryStride"`
+	Algorithm               string           `json:"algorithm"`
+	IslandCount             int              `json:"islandCount"`
+	Islands                 []IslandSettings `json:"islands"`
+	MigrationInterval       int              `json:"migrationInterval"`
+	Migrants                int              `json:"migrants"`
+	MigrationTopology       string           `json:"migrationTopology"`
+	SpeciesMetric           string           `json:"speciesMetric"`
+	SpeciesThreshold        float64          `json:"speciesThreshold"`
+	SpeciesStagnation       int              `json:"speciesStagnation"`
+	Seed                    int64            `json:"seed"`
+}
+
+// IslandSettings represents the settings of an island of the island model.
+type IslandSettings struct {
 	PopulationSize int     `json:"populationSize"`
 	MutationRate   float64 `json:"mutationRate"`
 	CrossoverRate  float64 `json:"crossoverRate"`
 }
 
+// Names of the configuration files inside ConfigDir.
+const (
+	GameSettingsFile    = "settings.json"
+	GeneticSettingsFile = "genetic_settings.json"
+)
+
 var (
+	// ConfigDir is the directory the settings files are read from.
+	ConfigDir = "configs"
 	// Settings represents the game settings.
 	Settings GameSettings
 	// DNASettings represents the genetic settings.
 	DNASettings GeneticSettings
 	once        sync.Once
 	geneticOnce sync.Once
+	gameErr     error
+	geneticErr  error
 )
 
-// LoadGameSettings loads the game settings from the settings.json file.
+// LoadGameSettings loads the game settings from the settings.json file in ConfigDir.
 func LoadGameSettings() (GameSettings, error) {
-	var err error
 	once.Do(func() {
-		data, readErr := os.ReadFile("configs/settings.json")
-		if readErr != nil {
-			err = readErr
-			return
-		}
-
-		unmarshalErr := json.Unmarshal(data, &Settings)
-		if unmarshalErr != nil {
-			err = unmarshalErr
-			return
-		}
+		gameErr = loadSettingsFile(GameSettingsFile, &Settings)
 	})
 
-	return Settings, err
+	return Settings, gameErr
 }
 
-// LoadGeneticSettings loads the genetic settings from the genetic_settings.json file.
+// LoadGeneticSettings loads the genetic settings from the genetic_settings.json file in ConfigDir.
 func LoadGeneticSettings() (GeneticSettings, error) {
-	var err error
 	geneticOnce.Do(func() {
-		data, readErr := os.ReadFile("configs/genetic_settings.json")
-		if readErr != nil {
-			err = readErr
-			return
-		}
-
-		unmarshalErr := json.Unmarshal(data, &DNASettings)
-		if unmarshalErr != nil {
-			err = unmarshalErr
-			return
-		}
+		geneticErr = loadSettingsFile(GeneticSettingsFile, &DNASettings)
 	})
 
-	return DNASettings, err
+	return DNASettings, geneticErr
+}
+
+// loadSettingsFile reads the named file from ConfigDir into target.
+// Errors carry the path of the file so they can be reported as is.
+func loadSettingsFile(name string, target any) error {
+	path := filepath.Join(ConfigDir, name)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
 }
 
 // Obstacle represents an object that the player must avoid.
 type Obstacle struct {
-	X      int
-	Y      int
-	Width  int
-	Height int
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
 }
@@ -0,0 +1,479 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ValidationError describes a problem with a field of a configuration file.
+type ValidationError struct {
+	File    string
+	Field   string
+	Message string
+}
+
+// Error returns the problem prefixed with the file and the field.
+func (e ValidationError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", e.File, e.Field, e.Message)
+}
+
+// ValidationErrors collects every problem found in the configuration.
+type ValidationErrors []ValidationError
+
+// Error lists all the problems, one per line.
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+
+	return fmt.Sprintf("invalid configuration:\n  %s", strings.Join(lines, "\n  "))
+}
+
+// Err returns the errors as an error, or nil when there are none.
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// ValidateFile checks that the file exists, is valid JSON, has no unknown keys
+// and that every value has the type of the field it is decoded into.
+func ValidateFile(path string, target any) ValidationErrors {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ValidationErrors{{File: path, Message: "file not found"}}
+		}
+
+		return ValidationErrors{{File: path, Message: err.Error()}}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if err := json.Unmarshal(data, new(any)); errors.As(err, &syntaxErr) {
+		return ValidationErrors{{File: path, Message: fmt.Sprintf("invalid JSON at offset %d: %s", syntaxErr.Offset, err)}}
+	}
+
+	return checkJSON(path, "", data, reflect.TypeOf(target).Elem())
+}
+
+// checkJSON compares the raw JSON value against the type it is decoded into.
+// Objects are checked key by key and arrays element by element, so every problem is reported.
+func checkJSON(file, field string, data json.RawMessage, t reflect.Type) ValidationErrors {
+	var errs ValidationErrors
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			return ValidationErrors{{File: file, Field: field, Message: "expected an object"}}
+		}
+
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value := object[key]
+			name := joinField(field, key)
+
+			fieldType, ok := jsonField(t, key)
+			if !ok {
+				errs = append(errs, ValidationError{File: file, Field: name, Message: unknownKeyMessage(t, key)})
+				continue
+			}
+
+			errs = append(errs, checkJSON(file, name, value, fieldType)...)
+		}
+	case reflect.Slice:
+		var array []json.RawMessage
+		if err := json.Unmarshal(data, &array); err != nil {
+			return ValidationErrors{{File: file, Field: field, Message: "expected an array"}}
+		}
+
+		for i, value := range array {
+			errs = append(errs, checkJSON(file, fmt.Sprintf("%s[%d]", field, i), value, t.Elem())...)
+		}
+	default:
+		if err := json.Unmarshal(data, reflect.New(t).Interface()); err != nil {
+			errs = append(errs, ValidationError{File: file, Field: field, Message: fmt.Sprintf("expected a %s, got %s", t.Kind(), data)})
+		}
+	}
+
+	return errs
+}
+
+// jsonField returns the type of the struct field with the given JSON key.
+func jsonField(t reflect.Type, key string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("json"), ",")[0] == key {
+			return t.Field(i).Type, true
+		}
+	}
+
+	return nil, false
+}
+
+// unknownKeyMessage explains an unknown key, suggesting the key with a different case if there is one.
+func unknownKeyMessage(t reflect.Type, key string) string {
+	for i := 0; i < t.NumField(); i++ {
+		known := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if known != "" && strings.EqualFold(known, key) {
+			return fmt.Sprintf("unknown key, did you mean %q?", known)
+		}
+	}
+
+	return "unknown key"
+}
+
+// joinField appends a key to a field path.
+func joinField(field, key string) string {
+	if field == "" {
+		return key
+	}
+
+	return field + "." + key
+}
+
+// ValidateGameSettings checks the ranges of the game settings.
+// Whether the current level and the curriculum levels exist is checked by the engine, which knows the built-in levels.
+func ValidateGameSettings(file string, s GameSettings) ValidationErrors {
+	var errs ValidationErrors
+
+	if s.CurrentLevel < 1 {
+		errs = append(errs, ValidationError{file, "currentLevel", fmt.Sprintf("must be at least 1, got %d", s.CurrentLevel)})
+	}
+
+	files := []struct{ field, path string }{
+		{"seedGenomeFile", s.SeedGenomeFile},
+		{"levelsFile", s.LevelsFile},
+	}
+
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+
+		if _, err := os.Stat(f.path); err != nil {
+			errs = append(errs, ValidationError{file, f.field, fmt.Sprintf("cannot read %q", f.path)})
+		}
+	}
+
+	switch s.LogFormat {
+	case "", LogText, LogJSON:
+	default:
+		errs = append(errs, ValidationError{file, "logFormat", oneOfMessage(s.LogFormat, LogText, LogJSON)})
+	}
+
+	switch s.LogLevel {
+	case "", LogDebug, LogInfo, LogWarn, LogError:
+	default:
+		errs = append(errs, ValidationError{file, "logLevel", oneOfMessage(s.LogLevel, LogDebug, LogInfo, LogWarn, LogError)})
+	}
+
+	if s.DashboardAddr != "" {
+		if problem := validateLoopback(s.DashboardAddr); problem != "" {
+			errs = append(errs, ValidationError{file, "dashboardAddr", problem})
+		}
+	}
+
+	if s.MetricsAddr != "" {
+		if _, _, err := net.SplitHostPort(s.MetricsAddr); err != nil {
+			errs = append(errs, ValidationError{file, "metricsAddr", fmt.Sprintf("must be host:port, got %q", s.MetricsAddr)})
+		} else if s.MetricsAddr == s.DashboardAddr {
+			errs = append(errs, ValidationError{file, "metricsAddr", "must differ from dashboardAddr"})
+		}
+	}
+
+	switch s.TrailMode {
+	case "", TrailSolid, TrailFade, TrailRank, TrailLineage, TrailBest:
+	default:
+		errs = append(errs, ValidationError{file, "trailMode", oneOfMessage(s.TrailMode, TrailSolid, TrailFade, TrailRank, TrailLineage, TrailBest)})
+	}
+
+	if s.TrailFade < 0 || s.TrailFade >= 1 {
+		errs = append(errs, ValidationError{file, "trailFade", fmt.Sprintf("must be at least 0 and less than 1, got %g", s.TrailFade)})
+	}
+
+	if s.TrailBest < 0 {
+		errs = append(errs, ValidationError{file, "trailBest", fmt.Sprintf("must not be negative, got %d", s.TrailBest)})
+	}
+
+	if s.RecordGeneration < 0 {
+		errs = append(errs, ValidationError{file, "recordGeneration", fmt.Sprintf("must not be negative, got %d", s.RecordGeneration)})
+	}
+
+	if s.RecordFrameStep < 0 {
+		errs = append(errs, ValidationError{file, "recordFrameStep", fmt.Sprintf("must not be negative, got %d", s.RecordFrameStep)})
+	}
+
+	if s.SnapshotFile != "" {
+		if s.SnapshotInterval < 1 {
+			errs = append(errs, ValidationError{file, "snapshotInterval", fmt.Sprintf("must be at least 1 with a snapshotFile, got %d", s.SnapshotInterval)})
+		}
+
+		if s.SnapshotBest < 1 {
+			errs = append(errs, ValidationError{file, "snapshotBest", fmt.Sprintf("must be at least 1 with a snapshotFile, got %d", s.SnapshotBest)})
+		}
+	}
+
+	for i, stage := range s.Curriculum {
+		field := fmt.Sprintf("curriculum[%d]", i)
+
+		if stage.Level < 1 {
+			errs = append(errs, ValidationError{file, joinField(field, "level"), fmt.Sprintf("must be at least 1, got %d", stage.Level)})
+		}
+
+		if stage.WinRate <= 0 || stage.WinRate > 1 {
+			errs = append(errs, ValidationError{file, joinField(field, "winRate"), fmt.Sprintf("must be greater than 0 and at most 1, got %g", stage.WinRate)})
+		}
+
+		if stage.MaxGenerations < 0 {
+			errs = append(errs, ValidationError{file, joinField(field, "maxGenerations"), fmt.Sprintf("must not be negative, got %d", stage.MaxGenerations)})
+		}
+	}
+
+	return errs
+}
+
+// ValidateGeneticSettings checks the ranges of the genetic settings and the constraints between them.
+func ValidateGeneticSettings(file string, s GeneticSettings) ValidationErrors {
+	var errs ValidationErrors
+
+	if s.Iterations < 0 {
+		errs = append(errs, ValidationError{file, "iterations", fmt.Sprintf("must not be negative, got %d", s.Iterations)})
+	}
+
+	if s.MaxGenerations < 1 {
+		errs = append(errs, ValidationError{file, "maxGenerations", fmt.Sprintf("must be at least 1, got %d", s.MaxGenerations)})
+	}
+
+	errs = append(errs, validatePopulationSize(file, "populationSize", s.PopulationSize)...)
+	errs = append(errs, validateRates(file,
+		rateField{"mutationRate", s.MutationRate},
+		rateField{"crossoverRate", s.CrossoverRate},
+		rateField{"noveltyWeight", s.NoveltyWeight},
+	)...)
+
+	switch s.FitnessMode {
+	case "", FitnessDistance:
+	case FitnessNovelty, FitnessHybrid:
+		if s.NoveltyNeighbors < 1 {
+			errs = append(errs, ValidationError{file, "noveltyNeighbors", fmt.Sprintf("must be at least 1 in %s mode, got %d", s.FitnessMode, s.NoveltyNeighbors)})
+		}
+	default:
+		errs = append(errs, ValidationError{file, "fitnessMode", oneOfMessage(s.FitnessMode, FitnessDistance, FitnessNovelty, FitnessHybrid)})
+	}
+
+	switch s.Algorithm {
+	case "", AlgorithmGA, AlgorithmNSGA2:
+	case AlgorithmSpecies:
+		if s.SpeciesThreshold <= 0 {
+			errs = append(errs, ValidationError{file, "speciesThreshold", fmt.Sprintf("must be positive with the %s algorithm, got %g", s.Algorithm, s.SpeciesThreshold)})
+		}
+	default:
+		errs = append(errs, ValidationError{file, "algorithm", oneOfMessage(s.Algorithm, AlgorithmGA, AlgorithmNSGA2, AlgorithmSpecies)})
+	}
+
+	switch s.SpeciesMetric {
+	case "", SpeciesGenome, SpeciesBehavior:
+	default:
+		errs = append(errs, ValidationError{file, "speciesMetric", oneOfMessage(s.SpeciesMetric, SpeciesGenome, SpeciesBehavior)})
+	}
+
+	if s.SpeciesStagnation < 0 {
+		errs = append(errs, ValidationError{file, "speciesStagnation", fmt.Sprintf("must not be negative, got %d", s.SpeciesStagnation)})
+	}
+
+	if s.NoveltyTrajectoryStride < 0 {
+		errs = append(errs, ValidationError{file, "noveltyTrajectoryStride", fmt.Sprintf("must not be negative, got %d", s.NoveltyTrajectoryStride)})
+	}
+
+	errs = append(errs, validateIslands(file, s)...)
+
+	return errs
+}
+
+// validateIslands checks the island model settings.
+// Every island breeds its own offspring, so each one needs a valid population size,
+// and the migrants of an island must leave some of its own individuals in place.
+func validateIslands(file string, s GeneticSettings) ValidationErrors {
+	var errs ValidationErrors
+
+	sizes := []int{}
+	switch {
+	case len(s.Islands) > 0:
+		for i, island := range s.Islands {
+			field := fmt.Sprintf("islands[%d]", i)
+			errs = append(errs, validatePopulationSize(file, joinField(field, "populationSize"), island.PopulationSize)...)
+			errs = append(errs, validateRates(file,
+				rateField{joinField(field, "mutationRate"), island.MutationRate},
+				rateField{joinField(field, "crossoverRate"), island.CrossoverRate},
+			)...)
+			sizes = append(sizes, island.PopulationSize)
+		}
+	case s.IslandCount < 0:
+		errs = append(errs, ValidationError{file, "islandCount", fmt.Sprintf("must not be negative, got %d", s.IslandCount)})
+	case s.IslandCount > 1:
+		if s.PopulationSize%(2*s.IslandCount) != 0 {
+			errs = append(errs, ValidationError{file, "populationSize", fmt.Sprintf("must split into %d islands of an even size, got %d", s.IslandCount, s.PopulationSize)})
+		}
+		for i := 0; i < s.IslandCount; i++ {
+			sizes = append(sizes, s.PopulationSize/s.IslandCount)
+		}
+	}
+
+	if s.MigrationInterval < 0 {
+		errs = append(errs, ValidationError{file, "migrationInterval", fmt.Sprintf("must not be negative, got %d", s.MigrationInterval)})
+	}
+
+	if s.Migrants < 0 {
+		errs = append(errs, ValidationError{file, "migrants", fmt.Sprintf("must not be negative, got %d", s.Migrants)})
+	}
+
+	for _, size := range sizes {
+		if size > 0 && s.Migrants >= size {
+			errs = append(errs, ValidationError{file, "migrants", fmt.Sprintf("must be smaller than every island, got %d for an island of %d", s.Migrants, size)})
+			break
+		}
+	}
+
+	switch s.MigrationTopology {
+	case "", TopologyRing, TopologyFull, TopologyRandom:
+	default:
+		errs = append(errs, ValidationError{file, "migrationTopology", oneOfMessage(s.MigrationTopology, TopologyRing, TopologyFull, TopologyRandom)})
+	}
+
+	return errs
+}
+
+// validatePopulationSize checks the size of a population.
+// The crossover loop produces two offspring from two different parents.
+func validatePopulationSize(file, field string, size int) ValidationErrors {
+	if size < 2 {
+		return ValidationErrors{{file, field, fmt.Sprintf("must be at least 2, got %d", size)}}
+	}
+
+	if size%2 != 0 {
+		return ValidationErrors{{file, field, fmt.Sprintf("must be even, got %d", size)}}
+	}
+
+	return nil
+}
+
+// rateField is a rate setting and the name of its field.
+type rateField struct {
+	field string
+	rate  float64
+}
+
+// validateRates checks that every rate is between 0 and 1.
+func validateRates(file string, rates ...rateField) ValidationErrors {
+	var errs ValidationErrors
+	for _, r := range rates {
+		if r.rate < 0 || r.rate > 1 {
+			errs = append(errs, ValidationError{file, r.field, fmt.Sprintf("must be between 0 and 1, got %g", r.rate)})
+		}
+	}
+
+	return errs
+}
+
+// validateLoopback checks that an address listens on the local machine only.
+// It returns the problem, or an empty string when the address is valid.
+func validateLoopback(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Sprintf("must be host:port, got %q", addr)
+	}
+
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Sprintf("must be on localhost or a loopback address, got %q", addr)
+	}
+
+	return ""
+}
+
+// oneOfMessage explains that a value is not one of the allowed ones.
+func oneOfMessage(value string, allowed ...string) string {
+	return fmt.Sprintf("must be one of %q, got %q", allowed, value)
+}
+
+// ValidateLevel checks the move limit and the wall geometry of a level.
+// Walls must have a positive size, stay inside the arena and leave the start and the goal free.
+func ValidateLevel(file, field string, moveLimit int, walls []Obstacle) ValidationErrors {
+	var errs ValidationErrors
+
+	if moveLimit < 1 || moveLimit >= MaxMoves {
+		errs = append(errs, ValidationError{file, joinField(field, "moveLimit"), fmt.Sprintf("must be between 1 and %d, got %d", MaxMoves-1, moveLimit)})
+	}
+
+	arena := image.Rect(0, 0, GameWidth, GameHeight)
+	start := image.Rect(StartX, StartY, StartX+5, StartY+5)
+	goal := image.Rect(GoalX, GoalY, GoalX+GoalSize, GoalY+GoalSize)
+
+	for i, wall := range walls {
+		name := fmt.Sprintf("%s[%d]", joinField(field, "walls"), i)
+		rect := image.Rect(wall.X, wall.Y, wall.X+wall.Width, wall.Y+wall.Height)
+
+		switch {
+		case wall.Width <= 0 || wall.Height <= 0:
+			errs = append(errs, ValidationError{file, name, fmt.Sprintf("must have a positive size, got %dx%d", wall.Width, wall.Height)})
+		case !rect.In(arena):
+			errs = append(errs, ValidationError{file, name, fmt.Sprintf("must be inside the %dx%d arena", GameWidth, GameHeight)})
+		case rect.Overlaps(start):
+			errs = append(errs, ValidationError{file, name, "covers the start position"})
+		case rect.Overlaps(goal):
+			errs = append(errs, ValidationError{file, name, "covers the goal"})
+		}
+	}
+
+	return errs
+}
+
+// ValidateLevelsFile checks the keys of a levels file and the geometry of its levels.
+func ValidateLevelsFile(path string) ValidationErrors {
+	errs := ValidateFile(path, &LevelsFile{})
+
+	// The geometry can only be checked when the file decodes.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errs
+	}
+
+	var file LevelsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return errs
+	}
+
+	seen := map[int]bool{}
+	for i, level := range file.Levels {
+		field := fmt.Sprintf("levels[%d]", i)
+
+		if level.Level < 1 {
+			errs = append(errs, ValidationError{path, joinField(field, "level"), fmt.Sprintf("must be at least 1, got %d", level.Level)})
+		} else if seen[level.Level] {
+			errs = append(errs, ValidationError{path, joinField(field, "level"), fmt.Sprintf("level %d is defined twice", level.Level)})
+		}
+		seen[level.Level] = true
+
+		errs = append(errs, ValidateLevel(path, field, level.MoveLimit, level.Walls)...)
+	}
+
+	return errs
+}
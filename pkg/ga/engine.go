@@ -0,0 +1,72 @@
+package ga
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// Engine runs the genetic algorithm on genomes of any type, with the operators given as functions.
+// Unlike Evolve, the genome type needs no methods, so plain types like []float64 or []int can be
+// evolved directly. The selection, elitism, statistics and callbacks are the same as in Evolve.
+type Engine[G any] struct {
+	Config Config
+	// Evaluate Returns the fitness of a genome. Higher fitness is better.
+	Evaluate func(genome G) float64
+	// Crossover Returns two offspring combining both parents.
+	Crossover func(a, b G, rng *rand.Rand) (G, G)
+	// Mutate Returns the genome mutated with the given rate.
+	Mutate func(genome G, rate float64, rng *rand.Rand) G
+	// Random Returns a random genome of the first generation.
+	Random func(rng *rand.Rand) G
+	// Clone Returns a copy of a genome. It is optional: without it the elites and the best genome
+	// share the genome they come from, which is safe as long as the operators never change
+	// the genomes they are given.
+	Clone     func(genome G) G
+	Callbacks Callbacks[G]
+}
+
+// Validate Checks the config and that the required functions are set. All the problems are reported at once.
+func (e *Engine[G]) Validate() error {
+	errs := []error{e.Config.Validate()}
+
+	if e.Evaluate == nil {
+		errs = append(errs, errors.New("Evaluate must be set"))
+	}
+
+	if e.Crossover == nil {
+		errs = append(errs, errors.New("Crossover must be set"))
+	}
+
+	if e.Mutate == nil {
+		errs = append(errs, errors.New("Mutate must be set"))
+	}
+
+	if e.Random == nil {
+		errs = append(errs, errors.New("Random must be set"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run Evolves config.PopulationSize random genomes for config.Generations generations,
+// or until OnGeneration stops it.
+func (e *Engine[G]) Run() (Result[G], error) {
+	if err := e.Validate(); err != nil {
+		return Result[G]{}, err
+	}
+
+	config := e.Config.withDefaults()
+
+	genomes := make([]G, config.PopulationSize)
+	for i := range genomes {
+		genomes[i] = e.Random(config.Rand)
+	}
+
+	clone := e.Clone
+	if clone == nil {
+		clone = func(genome G) G { return genome }
+	}
+
+	ops := operators[G]{crossover: e.Crossover, mutate: e.Mutate, clone: clone}
+	return evolve(config, genomes, EvaluateFunc[G](e.Evaluate), e.Callbacks, ops)
+}
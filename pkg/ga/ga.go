@@ -0,0 +1,223 @@
+// Package ga is a genetic algorithm library independent of the maze simulation and of Ebiten.
+//
+// A problem provides a genome type implementing Genome and an Evaluator that scores a
+// population. Evolve then runs the loop: evaluation, statistics, callbacks, and breeding
+// with selection, elitism, crossover and mutation. Breed exposes a single breeding step
+// for programs, like the maze, that drive the generations themselves.
+package ga
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Genome is a candidate solution. G is the genome type itself, e.g. a type Route
+// implements Genome[Route]. Genomes are values: the operators return new genomes
+// and must not change the ones they are given.
+type Genome[G any] interface {
+	// Crossover Returns two offspring combining the genome and the partner.
+	Crossover(partner G, rng *rand.Rand) (G, G)
+	// Mutate Returns the genome mutated with the given rate.
+	Mutate(rate float64, rng *rand.Rand) G
+	// Clone Returns a copy of the genome that shares no memory with it.
+	Clone() G
+}
+
+// Individual is a genome with its fitness. Higher fitness is better.
+type Individual[G any] struct {
+	Genome  G
+	Fitness float64
+}
+
+// Population is a generation of individuals.
+type Population[G any] []Individual[G]
+
+// Best Returns the fittest individual. The population must not be empty.
+func (p Population[G]) Best() Individual[G] {
+	best := 0
+	for i := range p {
+		if p[i].Fitness > p[best].Fitness {
+			best = i
+		}
+	}
+
+	return p[best]
+}
+
+// Stats Returns the fitness statistics of the population.
+func (p Population[G]) Stats() Stats {
+	stats := Stats{Best: math.Inf(-1), Worst: math.Inf(1)}
+	for i := range p {
+		stats.Best = math.Max(stats.Best, p[i].Fitness)
+		stats.Worst = math.Min(stats.Worst, p[i].Fitness)
+		stats.Avg += p[i].Fitness / float64(len(p))
+	}
+
+	return stats
+}
+
+// Evaluator scores the individuals of a population by setting their Fitness.
+// It receives the whole population, so simulations can run every individual at once.
+type Evaluator[G any] interface {
+	Evaluate(population Population[G]) error
+}
+
+// EvaluateFunc is an Evaluator that scores every genome on its own.
+type EvaluateFunc[G any] func(genome G) float64
+
+// Evaluate Sets the fitness of every individual.
+func (f EvaluateFunc[G]) Evaluate(population Population[G]) error {
+	for i := range population {
+		population[i].Fitness = f(population[i].Genome)
+	}
+
+	return nil
+}
+
+// Config holds the parameters of a run.
+type Config struct {
+	PopulationSize int
+	// Generations is the number of generations to evaluate.
+	Generations   int
+	MutationRate  float64
+	CrossoverRate float64
+	// Elitism is the number of fittest individuals copied unchanged to the next generation.
+	Elitism int
+	// Selection picks the parents, Roulette when nil.
+	Selection Selection
+	// Rand is the source of randomness, seeded from the clock when nil.
+	Rand *rand.Rand
+}
+
+// Validate Checks the parameters of the run. All the problems are reported at once.
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.PopulationSize < 2 {
+		errs = append(errs, fmt.Errorf("population size must be at least 2, got %d", c.PopulationSize))
+	}
+
+	if c.Generations < 1 {
+		errs = append(errs, fmt.Errorf("generations must be at least 1, got %d", c.Generations))
+	}
+
+	if c.MutationRate < 0 || c.MutationRate > 1 {
+		errs = append(errs, fmt.Errorf("mutation rate must be between 0 and 1, got %g", c.MutationRate))
+	}
+
+	if c.CrossoverRate < 0 || c.CrossoverRate > 1 {
+		errs = append(errs, fmt.Errorf("crossover rate must be between 0 and 1, got %g", c.CrossoverRate))
+	}
+
+	if c.Elitism < 0 || c.Elitism >= max(c.PopulationSize, 1) {
+		errs = append(errs, fmt.Errorf("elitism must be between 0 and the population size, got %d", c.Elitism))
+	}
+
+	return errors.Join(errs...)
+}
+
+// withDefaults Returns the config with the defaults of the optional fields.
+func (c Config) withDefaults() Config {
+	if c.Selection == nil {
+		c.Selection = Roulette()
+	}
+
+	if c.Rand == nil {
+		c.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	return c
+}
+
+// Stats holds the fitness statistics of an evaluated generation.
+type Stats struct {
+	Generation int
+	Best       float64
+	Avg        float64
+	Worst      float64
+}
+
+// Result is the outcome of a run.
+type Result[G any] struct {
+	// Best is the fittest individual of the whole run.
+	Best    Individual[G]
+	History []Stats
+}
+
+// Callbacks are called during a run. Every callback is optional.
+type Callbacks[G any] struct {
+	// OnGeneration is called after every evaluation. Returning false stops the run.
+	OnGeneration func(stats Stats, population Population[G]) bool
+	// OnNewBest is called when an individual beats the best fitness of the run.
+	OnNewBest func(generation int, best Individual[G])
+	// OnFinish is called once with the result of the run.
+	OnFinish func(result Result[G])
+}
+
+// Evolve Runs the genetic algorithm from the initial genomes for config.Generations generations,
+// or until OnGeneration stops it. Missing genomes are cloned from the initial ones and mutated,
+// and extra ones are dropped, so the population always has config.PopulationSize individuals.
+func Evolve[G Genome[G]](config Config, initial []G, evaluator Evaluator[G], callbacks Callbacks[G]) (Result[G], error) {
+	if err := config.Validate(); err != nil {
+		return Result[G]{}, err
+	}
+
+	if len(initial) == 0 {
+		return Result[G]{}, errors.New("the initial population is empty")
+	}
+
+	config = config.withDefaults()
+
+	genomes := make([]G, config.PopulationSize)
+	for i := range genomes {
+		genomes[i] = initial[i%len(initial)].Clone()
+		if i >= len(initial) {
+			genomes[i] = genomes[i].Mutate(config.MutationRate, config.Rand)
+		}
+	}
+
+	return evolve(config, genomes, evaluator, callbacks, methodOperators[G]())
+}
+
+// evolve Runs the generations from the first one with the given operators.
+// The config must be valid and have its defaults.
+func evolve[G any](config Config, genomes []G, evaluator Evaluator[G], callbacks Callbacks[G], ops operators[G]) (Result[G], error) {
+	var result Result[G]
+	for generation := 1; ; generation++ {
+		population := make(Population[G], len(genomes))
+		for i := range genomes {
+			population[i].Genome = genomes[i]
+		}
+
+		if err := evaluator.Evaluate(population); err != nil {
+			return result, err
+		}
+
+		stats := population.Stats()
+		stats.Generation = generation
+		result.History = append(result.History, stats)
+
+		if best := population.Best(); generation == 1 || best.Fitness > result.Best.Fitness {
+			result.Best = Individual[G]{Genome: ops.clone(best.Genome), Fitness: best.Fitness}
+			if callbacks.OnNewBest != nil {
+				callbacks.OnNewBest(generation, result.Best)
+			}
+		}
+
+		stop := callbacks.OnGeneration != nil && !callbacks.OnGeneration(stats, population)
+		if stop || generation >= config.Generations {
+			break
+		}
+
+		genomes = breed(config, population, config.PopulationSize, ops)
+	}
+
+	if callbacks.OnFinish != nil {
+		callbacks.OnFinish(result)
+	}
+
+	return result, nil
+}
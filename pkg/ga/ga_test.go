@@ -0,0 +1,148 @@
+package ga
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// bits is a OneMax genome: the fitness is the number of bits set.
+type bits []bool
+
+func (b bits) Crossover(partner bits, rng *rand.Rand) (bits, bits) {
+	point := rng.Intn(len(b)-1) + 1
+	return slices.Concat(b[:point], partner[point:]), slices.Concat(partner[:point], b[point:])
+}
+
+func (b bits) Mutate(rate float64, rng *rand.Rand) bits {
+	b = slices.Clone(b)
+	for i := range b {
+		if rng.Float64() < rate {
+			b[i] = !b[i]
+		}
+	}
+	return b
+}
+
+func (b bits) Clone() bits { return slices.Clone(b) }
+
+func countOnes(b bits) float64 {
+	count := 0.0
+	for _, bit := range b {
+		if bit {
+			count++
+		}
+	}
+	return count
+}
+
+func TestEvolveConverges(t *testing.T) {
+	const length = 32
+
+	for _, selection := range []struct {
+		name      string
+		selection Selection
+	}{
+		{"roulette", Roulette()},
+		{"tournament", Tournament(3)},
+	} {
+		t.Run(selection.name, func(t *testing.T) {
+			config := Config{
+				PopulationSize: 60,
+				Generations:    300,
+				MutationRate:   1.0 / length,
+				CrossoverRate:  0.9,
+				Elitism:        2,
+				Selection:      selection.selection,
+				Rand:           rand.New(rand.NewSource(1)),
+			}
+
+			generations := 0
+			callbacks := Callbacks[bits]{
+				OnGeneration: func(stats Stats, population Population[bits]) bool {
+					generations++
+					if len(population) != config.PopulationSize {
+						t.Fatalf("generation %d has %d individuals, want %d", stats.Generation, len(population), config.PopulationSize)
+					}
+					return stats.Best < length
+				},
+			}
+
+			result, err := Evolve(config, []bits{make(bits, length)}, EvaluateFunc[bits](countOnes), callbacks)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Best.Fitness != length {
+				t.Fatalf("best fitness %g after %d generations, want %d", result.Best.Fitness, generations, length)
+			}
+			if len(result.History) != generations {
+				t.Errorf("history has %d generations, want %d", len(result.History), generations)
+			}
+			if generations == config.Generations {
+				t.Errorf("the run did not stop once every bit was set")
+			}
+		})
+	}
+}
+
+func TestBreedKeepsElite(t *testing.T) {
+	population := Population[bits]{
+		{Genome: bits{true, true, true}, Fitness: 3},
+		{Genome: bits{false, false, false}, Fitness: 0},
+		{Genome: bits{true, false, false}, Fitness: 1},
+	}
+	config := Config{MutationRate: 1, CrossoverRate: 1, Elitism: 1, Rand: rand.New(rand.NewSource(1))}
+
+	offspring := Breed(config, population, 5)
+	if len(offspring) != 5 {
+		t.Fatalf("got %d offspring, want 5", len(offspring))
+	}
+	if !slices.Equal(offspring[0], population[0].Genome) {
+		t.Errorf("elite %v, want %v", offspring[0], population[0].Genome)
+	}
+
+	// The operators return new genomes, so the parents are unchanged.
+	if !slices.Equal(population[1].Genome, bits{false, false, false}) {
+		t.Errorf("parent changed to %v", population[1].Genome)
+	}
+}
+
+func TestSelectionWithoutFitness(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	fitness := []float64{0, 0, 0, 0}
+
+	seen := map[int]bool{}
+	for i := 0; i < 100; i++ {
+		seen[Roulette()(rng, fitness)] = true
+	}
+
+	if len(seen) != len(fitness) {
+		t.Errorf("roulette picked %d of %d individuals without fitness", len(seen), len(fitness))
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{PopulationSize: 10, Generations: 5, MutationRate: 0.1, CrossoverRate: 0.9, Elitism: 1}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+
+	invalid := Config{PopulationSize: 1, Generations: 0, MutationRate: -0.1, CrossoverRate: 1.5, Elitism: 1}
+	err := invalid.Validate()
+	if err == nil {
+		t.Fatal("invalid config accepted")
+	}
+
+	// Every problem is reported at once.
+	for _, want := range []string{"population size", "generations", "mutation rate", "crossover rate", "elitism"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+
+	if _, err := Evolve(invalid, []bits{make(bits, 4)}, EvaluateFunc[bits](countOnes), Callbacks[bits]{}); err == nil {
+		t.Error("Evolve accepted an invalid config")
+	}
+}
@@ -0,0 +1,130 @@
+package ga
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+)
+
+// maxParentDraws is the number of times the second parent is drawn again when it is the first one.
+const maxParentDraws = 10
+
+// Selection Returns the index of the individual picked as a parent, given the fitness of every individual.
+type Selection func(rng *rand.Rand, fitness []float64) int
+
+// Roulette Selects individuals with a probability proportional to their fitness.
+// Fitness is shifted to be non-negative, and every individual is equally likely when there is none.
+func Roulette() Selection {
+	return func(rng *rand.Rand, fitness []float64) int {
+		lowest := math.Min(slices.Min(fitness), 0)
+
+		total := 0.0
+		for _, f := range fitness {
+			total += f - lowest
+		}
+
+		if total <= 0 || math.IsInf(total, 0) || math.IsNaN(total) {
+			return rng.Intn(len(fitness))
+		}
+
+		selection := rng.Float64() * total
+		cumulative := 0.0
+		for i, f := range fitness {
+			cumulative += f - lowest
+			if selection < cumulative {
+				return i
+			}
+		}
+
+		return len(fitness) - 1
+	}
+}
+
+// Tournament Selects the fittest of size individuals drawn at random.
+func Tournament(size int) Selection {
+	return func(rng *rand.Rand, fitness []float64) int {
+		best := rng.Intn(len(fitness))
+		for i := 1; i < size; i++ {
+			if challenger := rng.Intn(len(fitness)); fitness[challenger] > fitness[best] {
+				best = challenger
+			}
+		}
+
+		return best
+	}
+}
+
+// operators are the genetic operators shared by Evolve and Engine.
+type operators[G any] struct {
+	crossover func(a, b G, rng *rand.Rand) (G, G)
+	mutate    func(genome G, rate float64, rng *rand.Rand) G
+	clone     func(genome G) G
+}
+
+// methodOperators Returns the operators of a genome type implementing Genome.
+func methodOperators[G Genome[G]]() operators[G] {
+	return operators[G]{
+		crossover: func(a, b G, rng *rand.Rand) (G, G) { return a.Crossover(b, rng) },
+		mutate:    func(genome G, rate float64, rng *rand.Rand) G { return genome.Mutate(rate, rng) },
+		clone:     func(genome G) G { return genome.Clone() },
+	}
+}
+
+// Breed Returns count genomes of the next generation of an evaluated population.
+// The config.Elitism fittest genomes are cloned unchanged. The others are the offspring of
+// parents picked with config.Selection, crossed with probability config.CrossoverRate
+// (otherwise cloned) and mutated with config.MutationRate.
+func Breed[G Genome[G]](config Config, population Population[G], count int) []G {
+	return breed(config, population, count, methodOperators[G]())
+}
+
+// breed Implements Breed with the given operators.
+func breed[G any](config Config, population Population[G], count int, ops operators[G]) []G {
+	if len(population) == 0 || count <= 0 {
+		return nil
+	}
+
+	config = config.withDefaults()
+	rng := config.Rand
+
+	fitness := make([]float64, len(population))
+	for i := range population {
+		fitness[i] = population[i].Fitness
+	}
+
+	offspring := make([]G, 0, count+1)
+
+	if config.Elitism > 0 {
+		order := make([]int, len(population))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return fitness[order[a]] > fitness[order[b]] })
+
+		for _, i := range order[:min(config.Elitism, len(order), count)] {
+			offspring = append(offspring, ops.clone(population[i].Genome))
+		}
+	}
+
+	for len(offspring) < count {
+		a := config.Selection(rng, fitness)
+		b := config.Selection(rng, fitness)
+		for draws := 0; b == a && draws < maxParentDraws && len(population) > 1; draws++ {
+			b = config.Selection(rng, fitness)
+		}
+
+		parentA, parentB := population[a].Genome, population[b].Genome
+
+		var childA, childB G
+		if rng.Float64() < config.CrossoverRate {
+			childA, childB = ops.crossover(parentA, parentB, rng)
+		} else {
+			childA, childB = ops.clone(parentA), ops.clone(parentB)
+		}
+
+		offspring = append(offspring, ops.mutate(childA, config.MutationRate, rng), ops.mutate(childB, config.MutationRate, rng))
+	}
+
+	return offspring[:count]
+}